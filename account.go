@@ -0,0 +1,278 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) restoreChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("chirp ID", err))
+		return
+	}
+	chirp, err := cfg.dbQueries.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:           chirpID,
+		UserID:       userID,
+		DeletedAfter: sql.NullTime{Time: time.Now().Add(-cfg.chirpUndoWindow).UTC(), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			clientErrorResponse(w, 404, errors.New("no recently deleted chirp with that id"))
+			return
+		}
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	writeJSON(w, 200, Chirp{Chirp: chirp})
+}
+
+// deleteAccountHandler schedules the account for deletion. The account and
+// its chirps disappear at once, and its access tokens stop working, but they
+// are only purged after the grace period; logging back in before then
+// cancels the deletion.
+func (cfg *apiConfig) deleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	tx, err := cfg.storage.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queriesTx(tx)
+	deletedAt, err := qtx.MarkUserDeleted(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			clientErrorResponse(w, 409, errors.New("account is already scheduled for deletion"))
+			return
+		}
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if err := qtx.SoftDeleteChirpsForUser(r.Context(), database.SoftDeleteChirpsForUserParams{
+		DeletedAt: deletedAt,
+		UserID:    userID,
+	}); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userID); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	cfg.denylist.Invalidate()
+	writeJSON(w, 202, struct {
+		DeletedAt  time.Time `json:"deleted_at"`
+		PurgeAfter time.Time `json:"purge_after"`
+	}{
+		DeletedAt:  deletedAt.Time,
+		PurgeAfter: deletedAt.Time.Add(cfg.accountDeletionGrace),
+	})
+}
+
+// cancelAccountDeletion brings back an account scheduled for deletion along
+// with the chirps removed with it.
+func (cfg *apiConfig) cancelAccountDeletion(ctx context.Context, userID uuid.UUID, deletedAt sql.NullTime) error {
+	tx, err := cfg.storage.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.queriesTx(tx)
+	if err := qtx.CancelUserDeletion(ctx, userID); err != nil {
+		return err
+	}
+	if err := qtx.RestoreChirpsForUser(ctx, database.RestoreChirpsForUserParams{
+		UserID:    userID,
+		DeletedAt: deletedAt,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	cfg.denylist.Invalidate()
+	return nil
+}
+
+type exportSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type exportChirp struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type exportMessage struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	Body           string    `json:"body"`
+}
+
+type accountExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Profile    map[string]any  `json:"profile"`
+	Chirps     []exportChirp   `json:"chirps"`
+	Sessions   []exportSession `json:"sessions"`
+	Messages   []exportMessage `json:"messages"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (cfg *apiConfig) buildAccountExport(r *http.Request, userID uuid.UUID) (accountExport, error) {
+	user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		return accountExport{}, err
+	}
+	chirps, err := cfg.dbQueries.GetAllChirpsForUser(r.Context(), userID)
+	if err != nil {
+		return accountExport{}, err
+	}
+	sessions, err := cfg.dbQueries.GetRefreshTokensForUser(r.Context(), userID)
+	if err != nil {
+		return accountExport{}, err
+	}
+	messages, err := cfg.dbQueries.GetMessagesSentByUser(r.Context(), userID)
+	if err != nil {
+		return accountExport{}, err
+	}
+	res := accountExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: map[string]any{
+			"id":                user.ID,
+			"created_at":        user.CreatedAt,
+			"updated_at":        user.UpdatedAt,
+			"email":             user.Email,
+			"is_chirpy_red":     user.IsChirpyRed,
+			"dm_allowlist_only": user.DmAllowlistOnly,
+			"deleted_at":        nullTimePtr(user.DeletedAt),
+		},
+		Chirps:   make([]exportChirp, 0, len(chirps)),
+		Sessions: make([]exportSession, 0, len(sessions)),
+		Messages: make([]exportMessage, 0, len(messages)),
+	}
+	for _, c := range chirps {
+		res.Chirps = append(res.Chirps, exportChirp{
+			ID:        c.ID,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+			Body:      c.Body,
+			Status:    c.Status,
+			PublishAt: nullTimePtr(c.PublishAt),
+			DeletedAt: nullTimePtr(c.DeletedAt),
+		})
+	}
+	for _, s := range sessions {
+		res.Sessions = append(res.Sessions, exportSession{
+			CreatedAt: s.CreatedAt,
+			UpdatedAt: s.UpdatedAt,
+			ExpiresAt: s.ExpiresAt,
+			RevokedAt: nullTimePtr(s.RevokedAt),
+		})
+	}
+	for _, m := range messages {
+		res.Messages = append(res.Messages, exportMessage{
+			ID:             m.ID,
+			CreatedAt:      m.CreatedAt,
+			ConversationID: m.ConversationID,
+			Body:           m.Body,
+		})
+	}
+	return res, nil
+}
+
+// exportAccountHandler returns everything stored about the user, as a ZIP of
+// JSON files by default or as a single JSON document with ?format=json.
+// Session entries leave out the refresh tokens themselves.
+func (cfg *apiConfig) exportAccountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "zip" && format != "json" {
+		clientErrorResponse(w, 400, fmt.Errorf("unknown export format: %s", format))
+		return
+	}
+	export, err := cfg.buildAccountExport(r, userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	filename := fmt.Sprintf("chirpy-export-%s", export.ExportedAt.Format("20060102-150405"))
+	if format == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		writeJSON(w, 200, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	w.WriteHeader(200)
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"chirps.json", export.Chirps},
+		{"sessions.json", export.Sessions},
+		{"messages.json", export.Messages},
+	}
+	for _, f := range files {
+		fw, err := archive.Create(f.name)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error writing export archive", "error", err)
+			return
+		}
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(f.data); err != nil {
+			slog.ErrorContext(r.Context(), "error writing export archive", "error", err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		slog.ErrorContext(r.Context(), "error writing export archive", "error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+)
+
+// middlewareAccountStatus refuses requests from restricted users as soon as
+// the denylist knows about them, without waiting for their access tokens to
+// expire. Banned and deleted users are refused everything; suspended users
+// can still read, so they're only refused requests that change something.
+// GraphQL requests are all POSTed, so the GraphQL handler refuses their
+// mutations itself.
+func (cfg *apiConfig) middlewareAccountStatus(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil || token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Refresh tokens and API keys aren't JWTs, and invalid access tokens
+		// are the handlers' to reject.
+		userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		restriction, err := cfg.denylist.Check(r.Context(), userID)
+		if err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		if restriction != nil && (!restriction.ReadOnly() || !readRequest(r)) {
+			clientErrorResponse(w, 403, restriction)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readRequest reports whether r may only read, as far as the middleware can
+// tell without reading its body.
+func readRequest(r *http.Request) bool {
+	if safeMethod(r.Method) {
+		return true
+	}
+	return r.Method == http.MethodPost && (r.URL.Path == "/api/graphql" || r.URL.Path == apiPrefix+"/graphql")
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
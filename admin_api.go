@@ -0,0 +1,398 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAdminPageSize  = 50
+	maxAdminPageSize      = 500
+	defaultStatsDays      = 30
+	maxStatsDays          = 366
+	maxStatusReasonLength = 500
+)
+
+var errNotAdmin = errors.New("admin access required")
+
+// AdminUser is a user as the admin API shows it.
+type AdminUser struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	IsAdmin     bool      `json:"is_admin"`
+	// Status is active, suspended or banned. A suspension that has run out
+	// shows as active.
+	Status         string     `json:"status"`
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	StatusReason   string     `json:"status_reason"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+}
+
+// AdminUserDetail adds activity counts to AdminUser.
+type AdminUserDetail struct {
+	AdminUser
+	ActiveSessions int64 `json:"active_sessions"`
+	Chirps         int64 `json:"chirps"`
+}
+
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type AdminStats struct {
+	Since           time.Time    `json:"since"`
+	Users           int64        `json:"users"`
+	ChirpyRedUsers  int64        `json:"chirpy_red_users"`
+	SuspendedUsers  int64        `json:"suspended_users"`
+	BannedUsers     int64        `json:"banned_users"`
+	PendingDeletion int64        `json:"pending_deletion"`
+	ActiveUsers     int64        `json:"active_users"`
+	SignupsPerDay   []DailyCount `json:"signups_per_day"`
+	ChirpsPerDay    []DailyCount `json:"chirps_per_day"`
+}
+
+// requireAdmin only lets requests from admins through to next.
+func (cfg *apiConfig) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := cfg.authenticate(r)
+		if err != nil {
+			clientErrorResponse(w, 401, err)
+			return
+		}
+		isAdmin, err := cfg.dbQueries.IsAdmin(r.Context(), userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		if !isAdmin {
+			clientErrorResponse(w, 403, errNotAdmin)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// parseAdminUserFilter reads the user search filters: q (part of the email),
+// domain (the email domain), chirpy_red, and created_after and
+// created_before (RFC 3339 timestamps or dates).
+func parseAdminUserFilter(r *http.Request) (database.AdminSearchUsersParams, error) {
+	var p database.AdminSearchUsersParams
+	query := r.URL.Query()
+	if v := query.Get("q"); v != "" {
+		p.Query = sql.NullString{String: v, Valid: true}
+	}
+	if v := query.Get("domain"); v != "" {
+		p.EmailDomain = sql.NullString{String: strings.TrimPrefix(v, "@"), Valid: true}
+	}
+	if v := query.Get("chirpy_red"); v != "" {
+		red, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid chirpy_red: %s", v)
+		}
+		p.IsChirpyRed = sql.NullBool{Bool: red, Valid: true}
+	}
+	for name, dst := range map[string]*sql.NullTime{
+		"created_after":  &p.CreatedAfter,
+		"created_before": &p.CreatedBefore,
+	} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, v); err != nil {
+				return p, fmt.Errorf("invalid %s: %s", name, v)
+			}
+		}
+		*dst = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	return p, nil
+}
+
+func (cfg *apiConfig) adminSearchUsersHandler(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAdminUserFilter(r)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	limit, offset, err := pageQuery(r, defaultAdminPageSize, maxAdminPageSize)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	params.MaxUsers, params.Skip = int32(limit), int32(offset)
+	rows, err := cfg.dbQueries.AdminSearchUsers(r.Context(), params)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	users := make([]AdminUser, 0, len(rows))
+	for _, u := range rows {
+		users = append(users, AdminUser{
+			ID:             u.ID,
+			CreatedAt:      u.CreatedAt,
+			UpdatedAt:      u.UpdatedAt,
+			Email:          u.Email,
+			IsChirpyRed:    u.IsChirpyRed,
+			IsAdmin:        u.IsAdmin,
+			Status:         accountStatus(u.Status, u.SuspendedUntil),
+			SuspendedUntil: nullTimePtr(u.SuspendedUntil),
+			StatusReason:   u.StatusReason,
+			DeletedAt:      nullTimePtr(u.DeletedAt),
+		})
+	}
+	writeJSON(w, 200, struct {
+		Users  []AdminUser `json:"users"`
+		Limit  int         `json:"limit"`
+		Offset int         `json:"offset"`
+	}{
+		Users:  users,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (cfg *apiConfig) adminGetUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("user ID", err))
+		return
+	}
+	u, err := cfg.dbQueries.AdminGetUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			clientErrorResponse(w, 404, errors.New("user not found"))
+			return
+		}
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	writeJSON(w, 200, AdminUserDetail{
+		AdminUser: AdminUser{
+			ID:             u.ID,
+			CreatedAt:      u.CreatedAt,
+			UpdatedAt:      u.UpdatedAt,
+			Email:          u.Email,
+			IsChirpyRed:    u.IsChirpyRed,
+			IsAdmin:        u.IsAdmin,
+			Status:         accountStatus(u.Status, u.SuspendedUntil),
+			SuspendedUntil: nullTimePtr(u.SuspendedUntil),
+			StatusReason:   u.StatusReason,
+			DeletedAt:      nullTimePtr(u.DeletedAt),
+		},
+		ActiveSessions: u.ActiveSessions,
+		Chirps:         u.Chirps,
+	})
+}
+
+// accountStatus is the status a user has now: a suspension that has run out
+// no longer counts.
+func accountStatus(status string, suspendedUntil sql.NullTime) string {
+	if denylist.Status(status) == denylist.Suspended && suspendedUntil.Valid && time.Now().After(suspendedUntil.Time) {
+		return string(denylist.Active)
+	}
+	return status
+}
+
+// setAccountStatus changes the status of the user in the path. Banning a user
+// also revokes their refresh tokens, so they're logged out everywhere.
+func (cfg *apiConfig) setAccountStatus(w http.ResponseWriter, r *http.Request, params database.SetUserStatusParams) bool {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("user ID", err))
+		return false
+	}
+	params.ID = userID
+	tx, err := cfg.storage.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return false
+	}
+	defer tx.Rollback()
+	qtx := cfg.queriesTx(tx)
+	n, err := qtx.SetUserStatus(r.Context(), params)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return false
+	}
+	if n == 0 {
+		clientErrorResponse(w, 404, errors.New("user not found"))
+		return false
+	}
+	if denylist.Status(params.Status) == denylist.Banned {
+		if err := qtx.RevokeAllRefreshTokensForUser(r.Context(), userID); err != nil {
+			serverErrorResponse(w, 500, err)
+			return false
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		serverErrorResponse(w, 500, err)
+		return false
+	}
+	cfg.denylist.Invalidate()
+	slog.InfoContext(r.Context(), "account status changed", "target_user_id", userID, "status", params.Status, "reason", params.StatusReason)
+	return true
+}
+
+type accountStatusRequest struct {
+	Until  *time.Time `json:"until"`
+	Reason string     `json:"reason"`
+}
+
+func decodeAccountStatusRequest(w http.ResponseWriter, r *http.Request) (accountStatusRequest, error) {
+	var req accountStatusRequest
+	if r.ContentLength == 0 {
+		return req, nil
+	}
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		return req, err
+	}
+	var v validate.Validator
+	v.Check(req.Until == nil || req.Until.After(time.Now()), "until", "not_future", "must be in the future")
+	v.Field("reason", req.Reason, validate.MaxLength(maxStatusReasonLength))
+	return req, v.Err()
+}
+
+// adminSuspendUserHandler suspends a user, until a given time or until the
+// suspension is lifted. Suspended users can log in and read, but can't post,
+// change or delete anything.
+func (cfg *apiConfig) adminSuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAccountStatusRequest(w, r)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	params := database.SetUserStatusParams{
+		Status:       string(denylist.Suspended),
+		StatusReason: req.Reason,
+	}
+	if req.Until != nil {
+		params.SuspendedUntil = sql.NullTime{Time: req.Until.UTC(), Valid: true}
+	}
+	if cfg.setAccountStatus(w, r, params) {
+		w.WriteHeader(204)
+	}
+}
+
+// adminBanUserHandler bans a user: they can't log in or use their access
+// tokens, and their refresh tokens are revoked.
+func (cfg *apiConfig) adminBanUserHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeAccountStatusRequest(w, r)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	if req.Until != nil {
+		clientErrorResponse(w, 400, errors.New("bans don't expire, suspend the user instead"))
+		return
+	}
+	if cfg.setAccountStatus(w, r, database.SetUserStatusParams{
+		Status:       string(denylist.Banned),
+		StatusReason: req.Reason,
+	}) {
+		w.WriteHeader(204)
+	}
+}
+
+// adminReinstateUserHandler lifts a suspension or ban.
+func (cfg *apiConfig) adminReinstateUserHandler(w http.ResponseWriter, r *http.Request) {
+	if cfg.setAccountStatus(w, r, database.SetUserStatusParams{Status: string(denylist.Active)}) {
+		w.WriteHeader(204)
+	}
+}
+
+// dailyCounts fills in the days between since and today that had no rows.
+func dailyCounts(since time.Time, days int, rows map[string]int64) []DailyCount {
+	counts := make([]DailyCount, 0, days)
+	for d := range days {
+		date := since.AddDate(0, 0, d).Format(time.DateOnly)
+		counts = append(counts, DailyCount{Date: date, Count: rows[date]})
+	}
+	return counts
+}
+
+// adminStats gathers totals for every user and daily activity over the
+// last days days, today included.
+func (cfg *apiConfig) adminStats(r *http.Request, days int) (AdminStats, error) {
+	ctx := r.Context()
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+	stats := AdminStats{Since: since}
+
+	totals, err := cfg.dbQueries.CountUsers(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.Users = totals.Total
+	stats.ChirpyRedUsers = totals.ChirpyRed
+	stats.SuspendedUsers = totals.Suspended
+	stats.BannedUsers = totals.Banned
+	stats.PendingDeletion = totals.PendingDeletion
+
+	if stats.ActiveUsers, err = cfg.dbQueries.CountActiveUsers(ctx, since); err != nil {
+		return stats, err
+	}
+	signups, err := cfg.dbQueries.SignupsPerDay(ctx, since)
+	if err != nil {
+		return stats, err
+	}
+	byDay := map[string]int64{}
+	for _, row := range signups {
+		byDay[row.Day.Format(time.DateOnly)] = row.Count
+	}
+	stats.SignupsPerDay = dailyCounts(since, days, byDay)
+
+	chirps, err := cfg.dbQueries.ChirpsPerDay(ctx, since)
+	if err != nil {
+		return stats, err
+	}
+	byDay = map[string]int64{}
+	for _, row := range chirps {
+		byDay[row.Day.Format(time.DateOnly)] = row.Count
+	}
+	stats.ChirpsPerDay = dailyCounts(since, days, byDay)
+	return stats, nil
+}
+
+func statsDays(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("days")
+	if v == "" {
+		return defaultStatsDays, nil
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("invalid days: %s", v)
+	}
+	return min(days, maxStatsDays), nil
+}
+
+func (cfg *apiConfig) adminStatsHandler(w http.ResponseWriter, r *http.Request) {
+	days, err := statsDays(r)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	stats, err := cfg.adminStats(r, days)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	writeJSON(w, 200, stats)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"slices"
+)
+
+const dashboardBarWidth = 200
+
+//go:embed templates/admin.html
+var templateFS embed.FS
+
+var dashboardTemplate = template.Must(template.New("admin.html").Funcs(template.FuncMap{
+	"maxCount": func(counts []DailyCount) int64 {
+		var m int64
+		for _, c := range counts {
+			m = max(m, c.Count)
+		}
+		return m
+	},
+	// barWidth scales count against the largest count shown, in pixels.
+	"barWidth": func(count, maxCount int64) int64 {
+		if maxCount == 0 {
+			return 0
+		}
+		return count * dashboardBarWidth / maxCount
+	},
+}).ParseFS(templateFS, "templates/admin.html"))
+
+type dashboardData struct {
+	Hits  uint64
+	Stats *AdminStats
+}
+
+func renderDashboard(w http.ResponseWriter, data dashboardData) {
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, data); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write(buf.Bytes())
+}
+
+// hitsHandler shows the file server hit counter to anyone.
+func (cfg *apiConfig) hitsHandler(w http.ResponseWriter, r *http.Request) {
+	renderDashboard(w, dashboardData{Hits: cfg.fileServerHits.Load()})
+}
+
+// dashboardHandler shows admins the hit counter along with user and chirp
+// statistics.
+func (cfg *apiConfig) dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	days, err := statsDays(r)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	stats, err := cfg.adminStats(r, days)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	// Newest first reads better on a page.
+	slices.Reverse(stats.SignupsPerDay)
+	slices.Reverse(stats.ChirpsPerDay)
+	renderDashboard(w, dashboardData{Hits: cfg.fileServerHits.Load(), Stats: &stats})
+}
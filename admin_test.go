@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/service/users"
+	"github.com/google/uuid"
+)
+
+// newTestAdmin sets the admin commands up as adminCommand does, against a
+// database of the test's own.
+func newTestAdmin(t *testing.T) *admin {
+	t.Helper()
+	store := newTestDB(t)
+	queries := store.newStore(store.db)
+	notifier := notify.New(queries, 64)
+	notifier.Start(1)
+	t.Cleanup(notifier.Close)
+	return &admin{
+		storage:  store,
+		queries:  queries,
+		users:    users.New(queries),
+		notifier: notifier,
+		in:       strings.NewReader(""),
+	}
+}
+
+// runAdmin runs the named command and returns what it printed.
+func runAdmin(t *testing.T, a *admin, command string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	a.out = &out
+	err := adminCommands[command](a, context.Background(), args)
+	return out.String(), err
+}
+
+// adminCreateUser creates a user with the CLI and returns their ID.
+func adminCreateUser(t *testing.T, a *admin, email, password string) uuid.UUID {
+	t.Helper()
+	out, err := runAdmin(t, a, "users create", "-o", "json", "-email", email, "-password", password)
+	if err != nil {
+		t.Fatalf("creating %s: %v", email, err)
+	}
+	var created []adminUser
+	if err := json.Unmarshal([]byte(out), &created); err != nil || len(created) != 1 {
+		t.Fatalf("creating %s printed %q", email, out)
+	}
+	return created[0].ID
+}
+
+type importCounts map[string]struct {
+	Imported int64 `json:"imported"`
+	Skipped  int64 `json:"skipped"`
+}
+
+func TestAdminExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := newTestAdmin(t)
+	walt := adminCreateUser(t, src, "walt@breakingbad.com", "heisenberg1")
+	jesse := adminCreateUser(t, src, "jesse@breakingbad.com", "yeahscience1")
+	for _, c := range []struct {
+		author uuid.UUID
+		body   string
+	}{{walt, "say my name"}, {walt, "I am the one who knocks"}, {jesse, "yeah science"}} {
+		if _, err := src.queries.AddChirp(ctx, database.AddChirpParams{ChirpBody: c.body, ID: c.author}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(t.TempDir(), "export.json")
+	if _, err := runAdmin(t, src, "export", "-file", file); err != nil {
+		t.Fatal(err)
+	}
+
+	// Jesse's email is taken by someone else where the export is imported,
+	// so Jesse and their chirp are skipped rather than failing the import.
+	dst := newTestAdmin(t)
+	adminCreateUser(t, dst, "jesse@breakingbad.com", "capncook1")
+	importFile := func(want importCounts) {
+		t.Helper()
+		out, err := runAdmin(t, dst, "import", "-o", "json", file)
+		if err != nil {
+			t.Fatalf("importing: %v", err)
+		}
+		var got importCounts
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("importing printed %q", out)
+		}
+		for kind, counts := range want {
+			if got[kind] != counts {
+				t.Errorf("%s: imported %d, skipped %d; want %d and %d", kind, got[kind].Imported, got[kind].Skipped, counts.Imported, counts.Skipped)
+			}
+		}
+	}
+	importFile(importCounts{
+		"users":  {Imported: 1, Skipped: 1},
+		"chirps": {Imported: 2, Skipped: 1},
+	})
+	chirps, err := dst.queries.GetAllChirpsForExport(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chirps) != 2 || chirps[0].UserID != walt || chirps[1].UserID != walt {
+		t.Errorf("imported chirps %+v, want Walt's two", chirps)
+	}
+	if _, err := runAdmin(t, dst, "users reset-password", "-password", "heisenberg2", walt.String()); err != nil {
+		t.Errorf("resetting an imported user's password: %v", err)
+	}
+
+	// Everything is there already the second time.
+	importFile(importCounts{
+		"users":  {Imported: 0, Skipped: 2},
+		"chirps": {Imported: 0, Skipped: 3},
+	})
+}
+
+func TestAdminUsers(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAdmin(t)
+	a.in = strings.NewReader("heisenberg1\n")
+	walt := adminCreateUser(t, a, "walt@breakingbad.com", "")
+	adminCreateUser(t, a, "jesse@breakingbad.com", "yeahscience1")
+
+	if _, err := runAdmin(t, a, "users create", "-email", "walt@breakingbad.com", "-password", "heisenberg1"); err == nil {
+		t.Error("creating a user with a taken email succeeded")
+	}
+	if _, err := runAdmin(t, a, "users create", "-email", "skyler@breakingbad.com", "-password", "short"); err == nil || !strings.Contains(fieldErrors(err).Error(), "password") {
+		t.Errorf("creating a user with a short password: err = %v, want the password refused", err)
+	}
+
+	for _, tt := range []struct {
+		command string
+		args    []string
+		want    int
+	}{
+		{"users list", nil, 2},
+		{"users list", []string{"-limit", "1"}, 1},
+		{"users search", []string{"jesse"}, 1},
+		{"users search", []string{"saul"}, 0},
+	} {
+		out, err := runAdmin(t, a, tt.command, append([]string{"-o", "json"}, tt.args...)...)
+		if err != nil {
+			t.Fatalf("%s %v: %v", tt.command, tt.args, err)
+		}
+		var listed []adminUser
+		if err := json.Unmarshal([]byte(out), &listed); err != nil || len(listed) != tt.want {
+			t.Errorf("%s %v printed %q, want %d users", tt.command, tt.args, out, tt.want)
+		}
+	}
+
+	user := func() database.AdminGetUserRow {
+		t.Helper()
+		u, err := a.queries.AdminGetUser(ctx, walt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	steps := []struct {
+		command string
+		check   func(database.AdminGetUserRow) bool
+	}{
+		{"users grant-admin", func(u database.AdminGetUserRow) bool { return u.IsAdmin }},
+		{"users revoke-admin", func(u database.AdminGetUserRow) bool { return !u.IsAdmin }},
+		{"users grant-red", func(u database.AdminGetUserRow) bool { return u.IsChirpyRed }},
+		{"users revoke-red", func(u database.AdminGetUserRow) bool { return !u.IsChirpyRed }},
+	}
+	for _, step := range steps {
+		// Users are found by email as well as by ID.
+		for _, ref := range []string{walt.String(), "walt@breakingbad.com"} {
+			if _, err := runAdmin(t, a, step.command, ref); err != nil {
+				t.Fatalf("%s %s: %v", step.command, ref, err)
+			}
+			if !step.check(user()) {
+				t.Errorf("%s %s didn't take effect", step.command, ref)
+			}
+		}
+	}
+	if _, err := runAdmin(t, a, "users grant-admin", "saul@goodman.com"); err == nil {
+		t.Error("granting admin to an unknown user succeeded")
+	}
+
+	a.in = strings.NewReader("heisenberg2\n")
+	if _, err := runAdmin(t, a, "users reset-password", "walt@breakingbad.com"); err != nil {
+		t.Fatal(err)
+	}
+	all, err := a.queries.GetAllUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range all {
+		if u.ID != walt {
+			continue
+		}
+		if ok, _ := auth.CheckPasswordHash("heisenberg2", u.HashedPassword); !ok {
+			t.Error("the password wasn't reset")
+		}
+	}
+}
+
+func TestAdminChirps(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAdmin(t)
+	walt := adminCreateUser(t, a, "walt@breakingbad.com", "heisenberg1")
+	chirp, err := a.queries.AddChirp(ctx, database.AddChirpParams{ChirpBody: "say my name", ID: walt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	visible := func() bool {
+		t.Helper()
+		_, err := a.queries.GetChirpByID(ctx, database.GetChirpByIDParams{ID: chirp.ID})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	if _, err := runAdmin(t, a, "chirps delete", chirp.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if visible() {
+		t.Error("the deleted chirp is still visible")
+	}
+	if _, err := runAdmin(t, a, "chirps delete", chirp.ID.String()); err == nil {
+		t.Error("deleting a deleted chirp succeeded")
+	}
+	if _, err := runAdmin(t, a, "chirps restore", chirp.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+	if !visible() {
+		t.Error("the restored chirp isn't visible")
+	}
+	if _, err := runAdmin(t, a, "chirps restore", chirp.ID.String()); err == nil {
+		t.Error("restoring a live chirp succeeded")
+	}
+
+	// Closing the notifier waits for the author to have been told.
+	a.notifier.Close()
+	notifications, err := a.queries.GetNotificationsForUser(ctx, database.GetNotificationsForUserParams{UserID: walt, PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 1 || notifications[0].Type != string(notify.TypeChirpRemoved) {
+		t.Errorf("the author was notified of %+v, want the removal", notifications)
+	}
+
+	// The notifier is closed now, but the chirp is still deleted, and the
+	// command doesn't claim otherwise.
+	if _, err := runAdmin(t, a, "chirps delete", chirp.ID.String()); err != nil {
+		t.Errorf("deleting without notifying: %v", err)
+	}
+	if visible() {
+		t.Error("the chirp deleted without notifying is still visible")
+	}
+}
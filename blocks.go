@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503"
+	}
+	return sqlite.IsForeignKeyViolation(err)
+}
+
+// relationshipHandler builds the block/mute handlers, which all act between
+// the authenticated user and the user in the path.
+func (cfg *apiConfig) relationshipHandler(action func(ctx context.Context, userID, targetID uuid.UUID) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := cfg.authenticate(r)
+		if err != nil {
+			clientErrorResponse(w, 401, err)
+			return
+		}
+		targetID, err := uuid.Parse(r.PathValue("userID"))
+		if err != nil {
+			clientErrorResponse(w, 400, apierror.InvalidID("user ID", err))
+			return
+		}
+		if targetID == userID {
+			clientErrorResponse(w, 400, errors.New("cannot block or mute yourself"))
+			return
+		}
+		if err := action(r.Context(), userID, targetID); err != nil {
+			if isForeignKeyViolation(err) {
+				clientErrorResponse(w, 404, errors.New("user not found"))
+				return
+			}
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		w.WriteHeader(204)
+	}
+}
+
+func (cfg *apiConfig) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.relationshipHandler(func(ctx context.Context, userID, targetID uuid.UUID) error {
+		return cfg.dbQueries.BlockUser(ctx, database.BlockUserParams{BlockerID: userID, BlockedID: targetID})
+	})(w, r)
+}
+
+func (cfg *apiConfig) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.relationshipHandler(func(ctx context.Context, userID, targetID uuid.UUID) error {
+		return cfg.dbQueries.UnblockUser(ctx, database.UnblockUserParams{BlockerID: userID, BlockedID: targetID})
+	})(w, r)
+}
+
+func (cfg *apiConfig) muteUserHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.relationshipHandler(func(ctx context.Context, userID, targetID uuid.UUID) error {
+		return cfg.dbQueries.MuteUser(ctx, database.MuteUserParams{MuterID: userID, MutedID: targetID})
+	})(w, r)
+}
+
+func (cfg *apiConfig) unmuteUserHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.relationshipHandler(func(ctx context.Context, userID, targetID uuid.UUID) error {
+		return cfg.dbQueries.UnmuteUser(ctx, database.UnmuteUserParams{MuterID: userID, MutedID: targetID})
+	})(w, r)
+}
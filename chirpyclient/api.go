@@ -0,0 +1,203 @@
+package chirpyclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is a Chirpy user. Token and RefreshToken are only set by Login.
+type User struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Email        string    `json:"email"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	IsChirpyRed  bool      `json:"is_chirpy_red"`
+}
+
+// Chirp is a chirp. Status and PublishAt are only set on drafts and
+// scheduled chirps.
+type Chirp struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Status    string     `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+// NewChirp is a chirp to post. It's published straight away unless it's a
+// Draft or has a PublishAt.
+type NewChirp struct {
+	Body      string     `json:"body"`
+	Draft     bool       `json:"draft,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+// ListChirpsOptions filters and orders ListChirps.
+type ListChirpsOptions struct {
+	// AuthorID limits the list to one user's chirps.
+	AuthorID uuid.UUID
+	// Newest lists the newest chirps first instead of the oldest.
+	Newest bool
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// CreateUser signs up a new user. It doesn't log in.
+func (c *Client) CreateUser(ctx context.Context, email, password string) (*User, error) {
+	var u User
+	err := c.do(ctx, request{method: http.MethodPost, path: "/users", body: credentials{email, password}}, &u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// UpdateUser changes the logged in user's email and password.
+func (c *Client) UpdateUser(ctx context.Context, email, password string) (*User, error) {
+	var u User
+	err := c.do(ctx, request{method: http.MethodPut, path: "/users", body: credentials{email, password}, auth: accessAuth}, &u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Login logs in and keeps the user's tokens for the calls after it.
+func (c *Client) Login(ctx context.Context, email, password string) (*User, error) {
+	var u User
+	err := c.do(ctx, request{method: http.MethodPost, path: "/login", body: credentials{email, password}}, &u)
+	if err != nil {
+		return nil, err
+	}
+	c.setTokens(u.Token, u.RefreshToken)
+	return &u, nil
+}
+
+// Refresh gets a new access token with the refresh token, and returns it.
+// Calls refresh expired access tokens themselves, so this is rarely needed.
+func (c *Client) Refresh(ctx context.Context) (string, error) {
+	var res struct {
+		Token string `json:"token"`
+	}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/refresh", auth: refreshAuth}, &res); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.accessToken = res.Token
+	c.mu.Unlock()
+	return res.Token, nil
+}
+
+// Revoke revokes the refresh token, logging the client out once its
+// access token expires. The client forgets both tokens.
+func (c *Client) Revoke(ctx context.Context) error {
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/revoke", auth: refreshAuth}, nil); err != nil {
+		return err
+	}
+	c.setTokens("", "")
+	return nil
+}
+
+// CreateChirp posts a chirp as the logged in user.
+func (c *Client) CreateChirp(ctx context.Context, chirp NewChirp) (*Chirp, error) {
+	var res Chirp
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/chirps", body: chirp, auth: accessAuth}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ListChirps lists published chirps. When logged in, chirps by users the
+// user muted, or who blocked them, are left out.
+func (c *Client) ListChirps(ctx context.Context, opts ListChirpsOptions) ([]Chirp, error) {
+	query := url.Values{}
+	if opts.AuthorID != uuid.Nil {
+		query.Set("author_id", opts.AuthorID.String())
+	}
+	if opts.Newest {
+		query.Set("sort", "desc")
+	}
+	var res []Chirp
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/chirps", query: query, auth: c.optionalAuth()}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetChirp gets a chirp by ID.
+func (c *Client) GetChirp(ctx context.Context, id uuid.UUID) (*Chirp, error) {
+	var res Chirp
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/chirps/" + id.String(), auth: c.optionalAuth()}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteChirp deletes one of the user's chirps. It can be restored with
+// RestoreChirp for a while afterwards.
+func (c *Client) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/chirps/" + id.String(), auth: accessAuth}, nil)
+}
+
+// RestoreChirp undoes DeleteChirp.
+func (c *Client) RestoreChirp(ctx context.Context, id uuid.UUID) (*Chirp, error) {
+	var res Chirp
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/chirps/" + id.String() + "/restore", auth: accessAuth}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ScheduledChirps lists the user's drafts and scheduled chirps.
+func (c *Client) ScheduledChirps(ctx context.Context) ([]Chirp, error) {
+	var res []Chirp
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/chirps/scheduled", auth: accessAuth}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PublishScheduledChirp publishes a draft or scheduled chirp now.
+func (c *Client) PublishScheduledChirp(ctx context.Context, id uuid.UUID) (*Chirp, error) {
+	var res Chirp
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/chirps/scheduled/" + id.String() + "/publish", auth: accessAuth}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// DeleteScheduledChirp deletes a draft or scheduled chirp.
+func (c *Client) DeleteScheduledChirp(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/chirps/scheduled/" + id.String(), auth: accessAuth}, nil)
+}
+
+// PolkaWebhook sends a Polka event, as Polka does. It needs WithPolkaKey.
+func (c *Client) PolkaWebhook(ctx context.Context, event string, userID uuid.UUID) error {
+	type data struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	body := struct {
+		Event string `json:"event"`
+		Data  data   `json:"data"`
+	}{event, data{userID}}
+	return c.do(ctx, request{method: http.MethodPost, path: "/polka/webhooks", body: body, auth: polkaAuth}, nil)
+}
+
+// optionalAuth authenticates requests that work either way when logged in.
+func (c *Client) optionalAuth() auth {
+	if access, _ := c.Tokens(); access != "" {
+		return accessAuth
+	}
+	return noAuth
+}
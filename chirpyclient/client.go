@@ -0,0 +1,278 @@
+// Package chirpyclient is a client for the Chirpy API.
+//
+// A Client keeps the tokens from the last Login. When an access token has
+// expired it gets a new one with the refresh token and tries again, so
+// callers only need to log in once. Calls that are safe to repeat (GET, PUT
+// and DELETE) are retried when the server is unreachable or unavailable.
+// Errors from the server are returned as *Error.
+package chirpyclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBasePath is where the API is served on the server.
+const DefaultBasePath = "/api/v1"
+
+// ErrNotLoggedIn is returned by calls that need a token before Login.
+var ErrNotLoggedIn = errors.New("chirpyclient: not logged in")
+
+// Client calls the Chirpy API. It's safe for concurrent use.
+type Client struct {
+	baseURL  string
+	http     *http.Client
+	polkaKey string
+	retries  int
+	backoff  time.Duration
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	// refreshing serializes refreshes, so concurrent calls that all see
+	// an expired token only refresh it once.
+	refreshing sync.Mutex
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests with c instead of http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) { cl.http = c }
+}
+
+// WithTokens starts the client with tokens from an earlier Login.
+func WithTokens(accessToken, refreshToken string) Option {
+	return func(cl *Client) {
+		cl.accessToken = accessToken
+		cl.refreshToken = refreshToken
+	}
+}
+
+// WithPolkaKey sets the API key PolkaWebhook authenticates with.
+func WithPolkaKey(key string) Option {
+	return func(cl *Client) { cl.polkaKey = key }
+}
+
+// WithRetries retries idempotent calls up to n times, waiting backoff
+// before the first retry and twice as long before each one after. The
+// default is 2 retries from 100ms.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(cl *Client) {
+		cl.retries = n
+		cl.backoff = backoff
+	}
+}
+
+// New returns a client for the server at baseURL, such as
+// "https://chirpy.example.com". The API is expected under DefaultBasePath
+// unless baseURL already has a path.
+func New(baseURL string, opts ...Option) *Client {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if u, err := url.Parse(baseURL); err == nil && u.Path == "" {
+		baseURL += DefaultBasePath
+	}
+	c := &Client{
+		baseURL: baseURL,
+		http:    http.DefaultClient,
+		retries: 2,
+		backoff: 100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokens returns the current access and refresh tokens, to store them for
+// WithTokens.
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accessToken, c.refreshToken
+}
+
+func (c *Client) setTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = accessToken
+	c.refreshToken = refreshToken
+}
+
+// auth is how a request authenticates.
+type auth int
+
+const (
+	noAuth auth = iota
+	accessAuth
+	refreshAuth
+	polkaAuth
+)
+
+// request is a call to the API, kept so it can be sent again.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	auth   auth
+}
+
+// do sends req and decodes the response into out, if it isn't nil. An
+// expired access token is refreshed once, and idempotent requests are
+// retried.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return err
+		}
+	}
+	resp, token, err := c.send(ctx, req, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && req.auth == accessAuth && c.canRefresh() {
+		resp.Body.Close()
+		if err := c.refreshAfter(ctx, token); err != nil {
+			return err
+		}
+		if resp, _, err = c.send(ctx, req, body); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("chirpyclient: decoding %s %s response: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// send sends req, retrying it if it's idempotent, and returns the response
+// and the token it was sent with.
+func (c *Client) send(ctx context.Context, req request, body []byte) (*http.Response, string, error) {
+	token, err := c.token(req.auth)
+	if err != nil {
+		return nil, "", err
+	}
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+	attempts := 1
+	if idempotent(req.method) {
+		attempts += c.retries
+	}
+	wait := c.backoff
+	for attempt := 1; ; attempt++ {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u, r)
+		if err != nil {
+			return nil, "", err
+		}
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		switch req.auth {
+		case accessAuth, refreshAuth:
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		case polkaAuth:
+			httpReq.Header.Set("Authorization", "ApiKey "+token)
+		}
+		resp, err := c.http.Do(httpReq)
+		if attempt >= attempts || !retryable(resp, err) {
+			return resp, token, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) token(a auth) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var token string
+	switch a {
+	case noAuth:
+		return "", nil
+	case accessAuth:
+		token = c.accessToken
+	case refreshAuth:
+		token = c.refreshToken
+	case polkaAuth:
+		if c.polkaKey == "" {
+			return "", errors.New("chirpyclient: no Polka API key")
+		}
+		return c.polkaKey, nil
+	}
+	if token == "" {
+		return "", ErrNotLoggedIn
+	}
+	return token, nil
+}
+
+func (c *Client) canRefresh() bool {
+	_, refresh := c.Tokens()
+	return refresh != ""
+}
+
+// refreshAfter gets a new access token to replace expired, unless another
+// call already has.
+func (c *Client) refreshAfter(ctx context.Context, expired string) error {
+	c.refreshing.Lock()
+	defer c.refreshing.Unlock()
+	if access, _ := c.Tokens(); access != expired {
+		return nil
+	}
+	_, err := c.Refresh(ctx)
+	return err
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package chirpyclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/chirpyclient"
+	"github.com/google/uuid"
+)
+
+func newClient(t *testing.T, h http.HandlerFunc, opts ...chirpyclient.Option) *chirpyclient.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	opts = append([]chirpyclient.Option{chirpyclient.WithRetries(2, time.Millisecond)}, opts...)
+	return chirpyclient.New(srv.URL, opts...)
+}
+
+func TestRefreshesExpiredAccessToken(t *testing.T) {
+	var refreshes atomic.Int32
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/refresh":
+			if r.Header.Get("Authorization") != "Bearer refresh" {
+				t.Errorf("refresh sent %q", r.Header.Get("Authorization"))
+			}
+			refreshes.Add(1)
+			w.Write([]byte(`{"token": "fresh"}`))
+		case "/api/v1/chirps/scheduled":
+			if r.Header.Get("Authorization") != "Bearer fresh" {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(401)
+				w.Write([]byte(`{"status": 401, "code": "unauthorized", "detail": "token is expired"}`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		}
+	}, chirpyclient.WithTokens("stale", "refresh"))
+
+	if _, err := c.ScheduledChirps(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := refreshes.Load(); n != 1 {
+		t.Errorf("refreshed %d times, want 1", n)
+	}
+	if access, _ := c.Tokens(); access != "fresh" {
+		t.Errorf("access token = %q, want the refreshed one", access)
+	}
+}
+
+func TestFailedRefreshReturnsItsError(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		if r.URL.Path == "/api/v1/refresh" {
+			w.Write([]byte(`{"status": 401, "code": "unauthorized", "detail": "token has been revoked"}`))
+			return
+		}
+		w.Write([]byte(`{"status": 401, "code": "unauthorized", "detail": "token is expired"}`))
+	}, chirpyclient.WithTokens("stale", "revoked"))
+
+	_, err := c.ScheduledChirps(context.Background())
+	var e *chirpyclient.Error
+	if !errors.As(err, &e) || e.Detail != "token has been revoked" {
+		t.Errorf("err = %v, want the refresh's error", err)
+	}
+}
+
+func TestRetriesIdempotentCalls(t *testing.T) {
+	var calls atomic.Int32
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	if _, err := c.ListChirps(context.Background(), chirpyclient.ListChirpsOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("sent %d requests, want 3", n)
+	}
+}
+
+func TestDoesNotRetryPost(t *testing.T) {
+	var calls atomic.Int32
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(503)
+	}, chirpyclient.WithTokens("access", "refresh"))
+	_, err := c.CreateChirp(context.Background(), chirpyclient.NewChirp{Body: "hello"})
+	var e *chirpyclient.Error
+	if !errors.As(err, &e) || e.StatusCode != 503 {
+		t.Errorf("err = %v, want a 503", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+func TestDecodesProblems(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(422)
+		w.Write([]byte(`{"type": "about:blank", "title": "Unprocessable Entity", "status": 422, "detail": "request is invalid", "code": "validation_failed",
+			"errors": [{"field": "email", "code": "invalid_email", "message": "must be an email address"}]}`))
+	})
+	_, err := c.CreateUser(context.Background(), "walt", "hunter22")
+	var e *chirpyclient.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %v, want an *Error", err)
+	}
+	if e.StatusCode != 422 || e.Code != chirpyclient.CodeValidation || len(e.Fields) != 1 || e.Fields[0].Field != "email" {
+		t.Errorf("err = %+v", e)
+	}
+	if !chirpyclient.HasCode(err, chirpyclient.CodeValidation) {
+		t.Error("HasCode = false")
+	}
+}
+
+func TestNeedsLogin(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("sent %s %s", r.Method, r.URL)
+	})
+	if err := c.DeleteChirp(context.Background(), uuid.New()); !errors.Is(err, chirpyclient.ErrNotLoggedIn) {
+		t.Errorf("err = %v, want ErrNotLoggedIn", err)
+	}
+}
+
+func TestContextCancelsRetries(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(503)
+	}, chirpyclient.WithRetries(5, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.ListChirps(ctx, chirpyclient.ListChirpsOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's error", err)
+	}
+}
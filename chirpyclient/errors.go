@@ -0,0 +1,93 @@
+package chirpyclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error codes the server reports. See docs/endpoints.md for what they mean.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeInvalidJSON        = "invalid_json"
+	CodeInvalidID          = "invalid_id"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeRequestTooLarge    = "request_too_large"
+	CodeValidation         = "validation_failed"
+	CodeChirpTooLong       = "chirp_too_long"
+	CodeAccountSuspended   = "account_suspended"
+	CodeAccountBanned      = "account_banned"
+	CodeAccountDeleted     = "account_deleted"
+	CodeInternal           = "internal_error"
+)
+
+// FieldError is a problem with one field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is an error response from the server.
+type Error struct {
+	StatusCode int
+	// Code is the server's error code, one of the Code constants.
+	Code   string
+	Title  string
+	Detail string
+	// Fields lists the problems with each field of an invalid request.
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("chirpy: %d %s", e.StatusCode, e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("; %s %s", f.Field, f.Message)
+	}
+	return msg
+}
+
+// HasCode reports whether err is an *Error with code.
+func HasCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// IsNotFound reports whether err is the server saying there's no such
+// thing.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+func decodeError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	e := &Error{StatusCode: resp.StatusCode}
+	var problem struct {
+		Title  string       `json:"title"`
+		Detail string       `json:"detail"`
+		Code   string       `json:"code"`
+		Errors []FieldError `json:"errors"`
+	}
+	if json.Unmarshal(data, &problem) == nil && problem.Code != "" {
+		e.Code = problem.Code
+		e.Title = problem.Title
+		e.Detail = problem.Detail
+		e.Fields = problem.Errors
+		return e
+	}
+	// Not problem details: a proxy in front of the server, say.
+	e.Title = http.StatusText(resp.StatusCode)
+	e.Detail = strings.TrimSpace(string(data))
+	return e
+}
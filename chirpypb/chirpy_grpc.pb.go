@@ -0,0 +1,351 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: chirpy/v1/chirpy.proto
+
+package chirpypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ChirpyService_CreateUser_FullMethodName  = "/chirpy.v1.ChirpyService/CreateUser"
+	ChirpyService_Login_FullMethodName       = "/chirpy.v1.ChirpyService/Login"
+	ChirpyService_Refresh_FullMethodName     = "/chirpy.v1.ChirpyService/Refresh"
+	ChirpyService_CreateChirp_FullMethodName = "/chirpy.v1.ChirpyService/CreateChirp"
+	ChirpyService_ListChirps_FullMethodName  = "/chirpy.v1.ChirpyService/ListChirps"
+	ChirpyService_DeleteChirp_FullMethodName = "/chirpy.v1.ChirpyService/DeleteChirp"
+)
+
+// ChirpyServiceClient is the client API for ChirpyService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ChirpyService is the gRPC API, served on its own port (GRPC_ADDR). It runs the
+// same operations as the REST API.
+//
+// CreateChirp and DeleteChirp need an access token in the "authorization"
+// metadata, as "Bearer TOKEN". ListChirps takes one optionally, to leave
+// out chirps the user can't or doesn't want to see.
+//
+// Errors carry a google.rpc.ErrorInfo whose reason is the REST API's error
+// code, in the "chirpy" domain, and a google.rpc.BadRequest listing the
+// invalid fields of a request that failed validation.
+type ChirpyServiceClient interface {
+	// CreateUser signs up a new user.
+	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error)
+	// Login returns an access token, valid for an hour, and a refresh token.
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// Refresh returns a new access token for a refresh token.
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+	// CreateChirp publishes a chirp.
+	CreateChirp(ctx context.Context, in *CreateChirpRequest, opts ...grpc.CallOption) (*CreateChirpResponse, error)
+	// ListChirps streams published chirps, oldest first unless newest_first
+	// is set.
+	ListChirps(ctx context.Context, in *ListChirpsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListChirpsResponse], error)
+	// DeleteChirp deletes one of the user's chirps.
+	DeleteChirp(ctx context.Context, in *DeleteChirpRequest, opts ...grpc.CallOption) (*DeleteChirpResponse, error)
+}
+
+type chirpyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChirpyServiceClient(cc grpc.ClientConnInterface) ChirpyServiceClient {
+	return &chirpyServiceClient{cc}
+}
+
+func (c *chirpyServiceClient) CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*CreateUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateUserResponse)
+	err := c.cc.Invoke(ctx, ChirpyService_CreateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chirpyServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, ChirpyService_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chirpyServiceClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RefreshResponse)
+	err := c.cc.Invoke(ctx, ChirpyService_Refresh_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chirpyServiceClient) CreateChirp(ctx context.Context, in *CreateChirpRequest, opts ...grpc.CallOption) (*CreateChirpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateChirpResponse)
+	err := c.cc.Invoke(ctx, ChirpyService_CreateChirp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chirpyServiceClient) ListChirps(ctx context.Context, in *ListChirpsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListChirpsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ChirpyService_ServiceDesc.Streams[0], ChirpyService_ListChirps_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListChirpsRequest, ListChirpsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChirpyService_ListChirpsClient = grpc.ServerStreamingClient[ListChirpsResponse]
+
+func (c *chirpyServiceClient) DeleteChirp(ctx context.Context, in *DeleteChirpRequest, opts ...grpc.CallOption) (*DeleteChirpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteChirpResponse)
+	err := c.cc.Invoke(ctx, ChirpyService_DeleteChirp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChirpyServiceServer is the server API for ChirpyService service.
+// All implementations must embed UnimplementedChirpyServiceServer
+// for forward compatibility.
+//
+// ChirpyService is the gRPC API, served on its own port (GRPC_ADDR). It runs the
+// same operations as the REST API.
+//
+// CreateChirp and DeleteChirp need an access token in the "authorization"
+// metadata, as "Bearer TOKEN". ListChirps takes one optionally, to leave
+// out chirps the user can't or doesn't want to see.
+//
+// Errors carry a google.rpc.ErrorInfo whose reason is the REST API's error
+// code, in the "chirpy" domain, and a google.rpc.BadRequest listing the
+// invalid fields of a request that failed validation.
+type ChirpyServiceServer interface {
+	// CreateUser signs up a new user.
+	CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error)
+	// Login returns an access token, valid for an hour, and a refresh token.
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// Refresh returns a new access token for a refresh token.
+	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
+	// CreateChirp publishes a chirp.
+	CreateChirp(context.Context, *CreateChirpRequest) (*CreateChirpResponse, error)
+	// ListChirps streams published chirps, oldest first unless newest_first
+	// is set.
+	ListChirps(*ListChirpsRequest, grpc.ServerStreamingServer[ListChirpsResponse]) error
+	// DeleteChirp deletes one of the user's chirps.
+	DeleteChirp(context.Context, *DeleteChirpRequest) (*DeleteChirpResponse, error)
+	mustEmbedUnimplementedChirpyServiceServer()
+}
+
+// UnimplementedChirpyServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedChirpyServiceServer struct{}
+
+func (UnimplementedChirpyServiceServer) CreateUser(context.Context, *CreateUserRequest) (*CreateUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedChirpyServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedChirpyServiceServer) Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Refresh not implemented")
+}
+func (UnimplementedChirpyServiceServer) CreateChirp(context.Context, *CreateChirpRequest) (*CreateChirpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateChirp not implemented")
+}
+func (UnimplementedChirpyServiceServer) ListChirps(*ListChirpsRequest, grpc.ServerStreamingServer[ListChirpsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ListChirps not implemented")
+}
+func (UnimplementedChirpyServiceServer) DeleteChirp(context.Context, *DeleteChirpRequest) (*DeleteChirpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteChirp not implemented")
+}
+func (UnimplementedChirpyServiceServer) mustEmbedUnimplementedChirpyServiceServer() {}
+func (UnimplementedChirpyServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeChirpyServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChirpyServiceServer will
+// result in compilation errors.
+type UnsafeChirpyServiceServer interface {
+	mustEmbedUnimplementedChirpyServiceServer()
+}
+
+func RegisterChirpyServiceServer(s grpc.ServiceRegistrar, srv ChirpyServiceServer) {
+	// If the following call pancis, it indicates UnimplementedChirpyServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ChirpyService_ServiceDesc, srv)
+}
+
+func _ChirpyService_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpyServiceServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpyService_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpyServiceServer).CreateUser(ctx, req.(*CreateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChirpyService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpyServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpyService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpyServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChirpyService_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpyServiceServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpyService_Refresh_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpyServiceServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChirpyService_CreateChirp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateChirpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpyServiceServer).CreateChirp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpyService_CreateChirp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpyServiceServer).CreateChirp(ctx, req.(*CreateChirpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChirpyService_ListChirps_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListChirpsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChirpyServiceServer).ListChirps(m, &grpc.GenericServerStream[ListChirpsRequest, ListChirpsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ChirpyService_ListChirpsServer = grpc.ServerStreamingServer[ListChirpsResponse]
+
+func _ChirpyService_DeleteChirp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteChirpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChirpyServiceServer).DeleteChirp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChirpyService_DeleteChirp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChirpyServiceServer).DeleteChirp(ctx, req.(*DeleteChirpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ChirpyService_ServiceDesc is the grpc.ServiceDesc for ChirpyService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ChirpyService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chirpy.v1.ChirpyService",
+	HandlerType: (*ChirpyServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateUser",
+			Handler:    _ChirpyService_CreateUser_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _ChirpyService_Login_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _ChirpyService_Refresh_Handler,
+		},
+		{
+			MethodName: "CreateChirp",
+			Handler:    _ChirpyService_CreateChirp_Handler,
+		},
+		{
+			MethodName: "DeleteChirp",
+			Handler:    _ChirpyService_DeleteChirp_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListChirps",
+			Handler:       _ChirpyService_ListChirps_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "chirpy/v1/chirpy.proto",
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/chirpyclient"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+const (
+	testTokenSecret = "test-token-secret-test-token-secret-test-token-secret-test-token"
+	testPolkaKey    = "test-polka-key"
+)
+
+// newTestServer runs the real handlers, with a database of the test's own;
+// see newTestDB.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return serveTestConfig(t, newTestConfig(t))
+}
+
+// serveTestConfig serves the API as configured by cfg.
+func serveTestConfig(t *testing.T, cfg *apiConfig) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	handleRoutes(mux, cfg.routes(http.NotFoundHandler()))
+	srv := httptest.NewServer(cfg.middlewareAccountStatus(mux))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestConfig sets the server up as run does, with a database of the
+// test's own.
+func newTestConfig(t *testing.T) *apiConfig {
+	t.Helper()
+	store := newTestDB(t)
+	queries := store.queries(store.db)
+	notifier := notify.New(queries, 64)
+	notifier.Start(1)
+	t.Cleanup(notifier.Close)
+	stopStreams := make(chan struct{})
+	t.Cleanup(func() { close(stopStreams) })
+	cfg := &apiConfig{
+		storage:              store,
+		dbQueries:            queries,
+		platform:             platformDev,
+		tokenSecret:          testTokenSecret,
+		polkaAPIKey:          testPolkaKey,
+		notifier:             notifier,
+		bus:                  pubsub.NewMemoryBus(),
+		chirpUndoWindow:      time.Minute,
+		accountDeletionGrace: time.Hour,
+		stopStreams:          stopStreams,
+		denylist:             denylist.New(queries, 0),
+	}
+	cfg.initServices()
+	return cfg
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	c := chirpyclient.New(srv.URL, chirpyclient.WithPolkaKey(testPolkaKey))
+	email := "walt-" + uuid.NewString()[:8] + "@breakingbad.com"
+
+	_, err := c.CreateUser(ctx, "walt", "short")
+	var invalid *chirpyclient.Error
+	if !errors.As(err, &invalid) || invalid.Code != chirpyclient.CodeValidation || len(invalid.Fields) != 2 {
+		t.Fatalf("CreateUser with a bad email and password: err = %v, want both fields invalid", err)
+	}
+	created, err := c.CreateUser(ctx, email, "heisenberg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Login(ctx, email, "wrong password 1"); !chirpyclient.HasCode(err, chirpyclient.CodeInvalidCredentials) {
+		t.Errorf("Login with the wrong password: err = %v", err)
+	}
+	user, err := c.Login(ctx, email, "heisenberg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != created.ID || user.Token == "" || user.RefreshToken == "" {
+		t.Fatalf("Login = %+v", user)
+	}
+
+	chirp, err := c.CreateChirp(ctx, chirpyclient.NewChirp{Body: "I am the one who knocks 👍🏽"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateChirp(ctx, chirpyclient.NewChirp{Body: strings.Repeat("x", 141)}); !chirpyclient.HasCode(err, chirpyclient.CodeChirpTooLong) {
+		t.Errorf("CreateChirp with 141 characters: err = %v", err)
+	}
+	got, err := c.GetChirp(ctx, chirp.ID)
+	if err != nil || got.Body != chirp.Body || got.UserID != user.ID {
+		t.Errorf("GetChirp = %+v, %v, want %+v", got, err, chirp)
+	}
+	list, err := c.ListChirps(ctx, chirpyclient.ListChirpsOptions{AuthorID: user.ID, Newest: true})
+	if err != nil || len(list) != 1 || list[0].ID != chirp.ID {
+		t.Errorf("ListChirps = %+v, %v, want the one chirp", list, err)
+	}
+
+	if err := c.DeleteChirp(ctx, chirp.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetChirp(ctx, chirp.ID); !chirpyclient.IsNotFound(err) {
+		t.Errorf("GetChirp after DeleteChirp: err = %v, want not found", err)
+	}
+	if _, err := c.RestoreChirp(ctx, chirp.ID); err != nil {
+		t.Errorf("RestoreChirp: %v", err)
+	}
+
+	draft, err := c.CreateChirp(ctx, chirpyclient.NewChirp{Body: "say my name", Draft: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if draft.Status != "draft" {
+		t.Errorf("draft status = %q", draft.Status)
+	}
+	if _, err := c.PublishScheduledChirp(ctx, draft.ID); err != nil {
+		t.Errorf("PublishScheduledChirp: %v", err)
+	}
+
+	if err := c.PolkaWebhook(ctx, "user.upgraded", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if user, err = c.Login(ctx, email, "heisenberg1"); err != nil || !user.IsChirpyRed {
+		t.Errorf("after the Polka upgrade, Login = %+v, %v, want Chirpy Red", user, err)
+	}
+
+	// An expired access token is refreshed without the caller noticing.
+	_, refreshToken := c.Tokens()
+	expired, err := auth.MakeJWT(user.ID, testTokenSecret, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2 := chirpyclient.New(srv.URL, chirpyclient.WithTokens(expired, refreshToken))
+	if _, err := c2.ScheduledChirps(ctx); err != nil {
+		t.Errorf("ScheduledChirps with an expired access token: %v", err)
+	}
+	if access, _ := c2.Tokens(); access == expired {
+		t.Error("the access token wasn't refreshed")
+	}
+
+	if err := c.Revoke(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chirpyclient.New(srv.URL, chirpyclient.WithTokens("", refreshToken)).Refresh(ctx); err == nil {
+		t.Error("Refresh with a revoked token succeeded")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+)
+
+// configCommand implements "chirpy config check", which prints the
+// configuration the server would run with, secrets redacted, and reports
+// every problem with it, including a database that can't be reached.
+func configCommand(args []string) error {
+	if len(args) == 0 || args[0] != "check" {
+		return errors.New("usage: chirpy config check [flags]")
+	}
+	conf, err := config.Load("chirpy config check", args[1:], os.Getenv)
+	var invalid *config.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		return err
+	}
+	conf.Print(os.Stdout)
+	if invalid != nil {
+		return err
+	}
+
+	store, err := openStorage(conf.DBURL)
+	if err != nil {
+		return err
+	}
+	defer store.db.Close()
+	if err := store.ping(context.Background()); err != nil {
+		return err
+	}
+	fmt.Println("configuration OK")
+	return nil
+}
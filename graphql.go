@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/dataloader"
+	"github.com/Blustak/bootdev-chirpy/internal/gqllimit"
+	"github.com/Blustak/bootdev-chirpy/internal/logging"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// graphqlSchema describes the GraphQL API. Its resolvers are below.
+//
+//go:embed docs/schema.graphql
+var graphqlSchema string
+
+const (
+	// A query can nest fields graphqlMaxDepth deep and resolve about
+	// graphqlMaxComplexity fields, counting a list's fields once per item
+	// its limit allows.
+	graphqlMaxDepth      = 8
+	graphqlMaxComplexity = 2000
+	// maxGraphQLListLimit is the most chirps a list field returns.
+	maxGraphQLListLimit = 100
+)
+
+// graphqlServer serves the GraphQL API. Queries are checked against the
+// limits before they run.
+type graphqlServer struct {
+	cfg     *apiConfig
+	schema  *graphql.Schema
+	limiter *gqllimit.Checker
+}
+
+// graphqlHandler returns the handler for /api/graphql. It panics if the
+// embedded schema doesn't match the resolvers.
+func (cfg *apiConfig) graphqlHandler() http.Handler {
+	limiter, err := gqllimit.New(graphqlSchema, gqllimit.Limits{
+		MaxDepth:      graphqlMaxDepth,
+		MaxComplexity: graphqlMaxComplexity,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &graphqlServer{
+		cfg: cfg,
+		schema: graphql.MustParseSchema(graphqlSchema, &graphqlResolver{cfg},
+			graphql.UseStringDescriptions(),
+			graphql.PanicHandler(graphqlPanicHandler{}),
+		),
+		limiter: limiter,
+	}
+}
+
+type graphqlParams struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    map[string]any `json:"extensions"`
+}
+
+// ServeHTTP runs a GraphQL request, given as query parameters to GET or as
+// a JSON body to POST. Mutations must be POSTed.
+func (s *graphqlServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params graphqlParams
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		params.Query = q.Get("query")
+		params.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &params.Variables); err != nil {
+				clientErrorResponse(w, 400, apierror.InvalidJSON(err))
+				return
+			}
+		}
+	} else if err := validate.DecodeJSON(w, r, &params, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	if params.Query == "" {
+		clientErrorResponse(w, 400, errors.New("query is required"))
+		return
+	}
+	viewer := s.cfg.optionalViewer(r)
+
+	if errs := s.schema.Validate(params.Query); len(errs) > 0 {
+		writeGraphQLErrors(w, errs, gqllimit.CodeInvalidQuery)
+		return
+	}
+	cost, err := s.limiter.Check(params.Query, params.OperationName, params.Variables)
+	if err != nil {
+		var limitErr *gqllimit.Error
+		if !errors.As(err, &limitErr) {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		logging.SetError(w, err)
+		writeGraphQLErrors(w, []*gqlerrors.QueryError{{Message: limitErr.Message}}, limitErr.Code)
+		return
+	}
+	if cost.Mutation && r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		clientErrorResponse(w, 405, errors.New("mutations must be sent with POST"))
+		return
+	}
+	// Restricted users get this far only with read-only restrictions.
+	if cost.Mutation && viewer.Valid {
+		restriction, err := s.cfg.denylist.Check(r.Context(), viewer.UUID)
+		if err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		if restriction != nil {
+			clientErrorResponse(w, 403, restriction)
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), graphqlRequestKey{}, s.cfg.newGraphQLRequest(viewer))
+	res := s.schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+	for _, e := range res.Errors {
+		if e.ResolverError == nil {
+			continue
+		}
+		p := apierror.ProblemFor(e.ResolverError)
+		if p.Status >= 500 {
+			logging.SetError(w, e.ResolverError)
+		}
+		e.Message = p.Detail
+		if e.Message == "" {
+			e.Message = p.Title
+		}
+		e.Extensions = map[string]any{"code": p.Code}
+		if len(p.Errors) > 0 {
+			e.Extensions["errors"] = p.Errors
+		}
+	}
+	writeJSON(w, 200, res)
+}
+
+// writeGraphQLErrors answers a request that couldn't run. Errors without a
+// code get code.
+func writeGraphQLErrors(w http.ResponseWriter, errs []*gqlerrors.QueryError, code string) {
+	for _, e := range errs {
+		if e.Extensions == nil {
+			e.Extensions = map[string]any{"code": code}
+		}
+	}
+	writeJSON(w, 200, graphql.Response{Errors: errs})
+}
+
+// graphqlPanicHandler hides a panicking resolver's value from the client;
+// ServeHTTP logs it as an internal error.
+type graphqlPanicHandler struct{}
+
+func (graphqlPanicHandler) MakePanicError(ctx context.Context, value any) *gqlerrors.QueryError {
+	return &gqlerrors.QueryError{ResolverError: fmt.Errorf("graphql resolver panicked: %v", value)}
+}
+
+type graphqlRequestKey struct{}
+
+// graphqlRequest is the state of one GraphQL request: who's asking, and
+// loaders that batch the lookups its resolvers make.
+type graphqlRequest struct {
+	viewer uuid.NullUUID
+	chirps *dataloader.Loader[uuid.UUID, *database.Chirp]
+	users  *dataloader.Loader[uuid.UUID, *database.GetUsersByIDsRow]
+	recent *dataloader.Loader[recentChirpsKey, []database.Chirp]
+}
+
+// recentChirpsKey asks for an author's limit newest chirps.
+type recentChirpsKey struct {
+	authorID uuid.UUID
+	limit    int32
+}
+
+func (cfg *apiConfig) newGraphQLRequest(viewer uuid.NullUUID) *graphqlRequest {
+	return &graphqlRequest{
+		viewer: viewer,
+		chirps: dataloader.New(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*database.Chirp, error) {
+			rows, err := cfg.dbQueries.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{Ids: ids, ViewerID: viewer})
+			if err != nil {
+				return nil, err
+			}
+			chirps := make(map[uuid.UUID]*database.Chirp, len(rows))
+			for i := range rows {
+				chirps[rows[i].ID] = &rows[i]
+			}
+			return chirps, nil
+		}),
+		users: dataloader.New(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*database.GetUsersByIDsRow, error) {
+			rows, err := cfg.dbQueries.GetUsersByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			users := make(map[uuid.UUID]*database.GetUsersByIDsRow, len(rows))
+			for i := range rows {
+				users[rows[i].ID] = &rows[i]
+			}
+			return users, nil
+		}),
+		recent: dataloader.New(func(ctx context.Context, keys []recentChirpsKey) (map[recentChirpsKey][]database.Chirp, error) {
+			// One query fetches the most any key asks for; each key gets
+			// its share.
+			var authors []uuid.UUID
+			var most int32
+			for _, k := range keys {
+				authors = append(authors, k.authorID)
+				most = max(most, k.limit)
+			}
+			rows, err := cfg.dbQueries.GetRecentChirpsByAuthors(ctx, database.GetRecentChirpsByAuthorsParams{
+				AuthorIds:    authors,
+				MaxPerAuthor: most,
+				ViewerID:     viewer,
+			})
+			if err != nil {
+				return nil, err
+			}
+			byAuthor := make(map[uuid.UUID][]database.Chirp)
+			for _, c := range rows {
+				byAuthor[c.UserID] = append(byAuthor[c.UserID], c)
+			}
+			chirps := make(map[recentChirpsKey][]database.Chirp, len(keys))
+			for _, k := range keys {
+				list := byAuthor[k.authorID]
+				chirps[k] = list[:min(len(list), int(k.limit))]
+			}
+			return chirps, nil
+		}),
+	}
+}
+
+func graphqlRequestFrom(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlRequestKey{}).(*graphqlRequest)
+}
+
+// graphqlResolver resolves the Query and Mutation fields.
+type graphqlResolver struct {
+	cfg *apiConfig
+}
+
+func parseGraphQLID(name string, id graphql.ID) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(string(id))
+	if err != nil {
+		return uuid.Nil, apierror.InvalidID(name, err)
+	}
+	return parsed, nil
+}
+
+func checkGraphQLLimit(limit int32) error {
+	if limit < 1 || limit > maxGraphQLListLimit {
+		return apierror.New(400, apierror.CodeBadRequest, fmt.Sprintf("limit must be 1 to %d", maxGraphQLListLimit))
+	}
+	return nil
+}
+
+// requireViewer returns the logged in user, or an error for anonymous
+// requests.
+func requireViewer(ctx context.Context) (uuid.UUID, error) {
+	viewer := graphqlRequestFrom(ctx).viewer
+	if !viewer.Valid {
+		return uuid.Nil, apierror.New(401, apierror.CodeUnauthorized, "not authorized")
+	}
+	return viewer.UUID, nil
+}
+
+func (q *graphqlResolver) Viewer(ctx context.Context) (*userResolver, error) {
+	viewer := graphqlRequestFrom(ctx).viewer
+	if !viewer.Valid {
+		return nil, nil
+	}
+	return loadUser(ctx, viewer.UUID)
+}
+
+func (q *graphqlResolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseGraphQLID("user ID", args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return loadUser(ctx, id)
+}
+
+func (q *graphqlResolver) Chirp(ctx context.Context, args struct{ ID graphql.ID }) (*chirpResolver, error) {
+	id, err := parseGraphQLID("chirp ID", args.ID)
+	if err != nil {
+		return nil, err
+	}
+	chirp, err := graphqlRequestFrom(ctx).chirps.Load(ctx, id)
+	if err != nil || chirp == nil {
+		return nil, err
+	}
+	return &chirpResolver{*chirp}, nil
+}
+
+func (q *graphqlResolver) Chirps(ctx context.Context, args struct {
+	AuthorID graphql.ID
+	Limit    int32
+}) ([]*chirpResolver, error) {
+	authorID, err := parseGraphQLID("author ID", args.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	return loadRecentChirps(ctx, authorID, args.Limit)
+}
+
+func (q *graphqlResolver) CreateChirp(ctx context.Context, args struct{ Body string }) (*chirpResolver, error) {
+	userID, err := requireViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	body, err := q.cfg.chirps.Prepare(ctx, userID, args.Body)
+	if err != nil {
+		return nil, err
+	}
+	chirp, err := q.cfg.chirps.Add(ctx, userID, body)
+	if err != nil {
+		return nil, err
+	}
+	return &chirpResolver{chirp}, nil
+}
+
+func (q *graphqlResolver) DeleteChirp(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	userID, err := requireViewer(ctx)
+	if err != nil {
+		return false, err
+	}
+	id, err := parseGraphQLID("chirp ID", args.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := q.cfg.chirps.Delete(ctx, userID, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func loadUser(ctx context.Context, id uuid.UUID) (*userResolver, error) {
+	user, err := graphqlRequestFrom(ctx).users.Load(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &userResolver{*user}, nil
+}
+
+func loadRecentChirps(ctx context.Context, authorID uuid.UUID, limit int32) ([]*chirpResolver, error) {
+	if err := checkGraphQLLimit(limit); err != nil {
+		return nil, err
+	}
+	chirps, err := graphqlRequestFrom(ctx).recent.Load(ctx, recentChirpsKey{authorID, limit})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*chirpResolver, len(chirps))
+	for i, c := range chirps {
+		res[i] = &chirpResolver{c}
+	}
+	return res, nil
+}
+
+type userResolver struct {
+	user database.GetUsersByIDsRow
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(u.user.ID.String())
+}
+
+func (u *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: u.user.CreatedAt}
+}
+
+func (u *userResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: u.user.UpdatedAt}
+}
+
+func (u *userResolver) Email(ctx context.Context) *string {
+	if viewer := graphqlRequestFrom(ctx).viewer; !viewer.Valid || viewer.UUID != u.user.ID {
+		return nil
+	}
+	return &u.user.Email
+}
+
+func (u *userResolver) IsChirpyRed() bool {
+	return u.user.IsChirpyRed
+}
+
+func (u *userResolver) Chirps(ctx context.Context, args struct{ Limit int32 }) ([]*chirpResolver, error) {
+	return loadRecentChirps(ctx, u.user.ID, args.Limit)
+}
+
+type chirpResolver struct {
+	chirp database.Chirp
+}
+
+func (c *chirpResolver) ID() graphql.ID {
+	return graphql.ID(c.chirp.ID.String())
+}
+
+func (c *chirpResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: c.chirp.CreatedAt}
+}
+
+func (c *chirpResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: c.chirp.UpdatedAt}
+}
+
+func (c *chirpResolver) Body() string {
+	return c.chirp.Body
+}
+
+func (c *chirpResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, c.chirp.UserID)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/chirpyclient"
+	"github.com/google/uuid"
+)
+
+type graphqlResult struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, url, token, query string) (int, graphqlResult) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var res graphqlResult
+	json.NewDecoder(resp.Body).Decode(&res)
+	return resp.StatusCode, res
+}
+
+func errorCode(res graphqlResult) string {
+	if len(res.Errors) == 0 {
+		return ""
+	}
+	code, _ := res.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+// TestGraphQLRequests covers what's refused before any resolver runs, so it
+// needs no database.
+func TestGraphQLRequests(t *testing.T) {
+	cfg := &apiConfig{tokenSecret: testTokenSecret}
+	cfg.initServices()
+	srv := httptest.NewServer(cfg.graphqlHandler())
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"anonymous viewer", `{ viewer { id } }`, ""},
+		{"invalid", `{ viewer { password } }`, "invalid_query"},
+		{"too deep", `{ viewer { chirps(limit: 1) { author { chirps(limit: 1) { author { chirps(limit: 1) { author { chirps(limit: 1) { id } } } } } } } } }`, "query_too_deep"},
+		{"too complex", `{ viewer { chirps(limit: 100) { author { chirps(limit: 100) { body } } } } }`, "query_too_complex"},
+		{"mutation without a token", `mutation { createChirp(body: "hello") { id } }`, "unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, res := postGraphQL(t, srv.URL, "", tt.query)
+			if status != 200 || errorCode(res) != tt.code {
+				t.Errorf("status %d, result %+v, want code %q", status, res, tt.code)
+			}
+		})
+	}
+
+	resp, err := http.Get(srv.URL + "?query=" + url.QueryEscape(`mutation { deleteChirp(id: "x") }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 405 {
+		t.Errorf("mutation with GET: status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	c := chirpyclient.New(srv.URL)
+	email := "jesse-" + uuid.NewString()[:8] + "@breakingbad.com"
+	if _, err := c.CreateUser(ctx, email, "yeahscience1"); err != nil {
+		t.Fatal(err)
+	}
+	user, err := c.Login(ctx, email, "yeahscience1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := srv.URL + "/api/v1/graphql"
+
+	_, res := postGraphQL(t, endpoint, user.Token, `mutation { createChirp(body: "yeah kerfuffle") { id body author { email } } }`)
+	if len(res.Errors) > 0 {
+		t.Fatalf("createChirp: %+v", res.Errors)
+	}
+	chirp := res.Data["createChirp"].(map[string]any)
+	if chirp["body"] != "yeah ****" || chirp["author"].(map[string]any)["email"] != email {
+		t.Errorf("createChirp = %v", chirp)
+	}
+
+	query := `{ viewer { chirps { id author { email } } } chirps(authorId: "` + user.ID.String() + `", limit: 1) { id } }`
+	_, anon := postGraphQL(t, endpoint, "", query)
+	if viewer := anon.Data["viewer"]; viewer != nil {
+		t.Errorf("anonymous viewer = %v, want null", viewer)
+	}
+	if list := anon.Data["chirps"].([]any); len(list) != 1 || list[0].(map[string]any)["id"] != chirp["id"] {
+		t.Errorf("chirps = %v, want the new chirp", list)
+	}
+	_, res = postGraphQL(t, endpoint, user.Token, query)
+	chirps := res.Data["viewer"].(map[string]any)["chirps"].([]any)
+	if len(chirps) != 1 || chirps[0].(map[string]any)["author"].(map[string]any)["email"] != email {
+		t.Errorf("viewer chirps = %v", chirps)
+	}
+
+	_, res = postGraphQL(t, endpoint, "", `mutation { deleteChirp(id: "`+chirp["id"].(string)+`") }`)
+	if errorCode(res) != "unauthorized" {
+		t.Errorf("anonymous deleteChirp: %+v", res)
+	}
+	_, res = postGraphQL(t, endpoint, user.Token, `mutation { deleteChirp(id: "`+chirp["id"].(string)+`") }`)
+	if res.Data["deleteChirp"] != true {
+		t.Errorf("deleteChirp: %+v", res)
+	}
+	_, res = postGraphQL(t, endpoint, user.Token, `mutation { deleteChirp(id: "`+chirp["id"].(string)+`") }`)
+	if errorCode(res) != "not_found" || !strings.Contains(res.Errors[0].Message, "not found") {
+		t.Errorf("deleting twice: %+v, want not found", res)
+	}
+}
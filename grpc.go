@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/chirpypb"
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/service/sessions"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcAuth is what a gRPC method needs from the caller.
+type grpcAuth int
+
+const (
+	// grpcRequired methods change something as the user, so suspended
+	// users are refused them. It's the zero value, so that a method
+	// missing from grpcMethodAuth isn't open to anyone.
+	grpcRequired grpcAuth = iota
+	// grpcOptional methods take an access token if there is one.
+	grpcOptional
+	grpcPublic
+)
+
+var grpcMethodAuth = map[string]grpcAuth{
+	chirpypb.ChirpyService_CreateUser_FullMethodName:  grpcPublic,
+	chirpypb.ChirpyService_Login_FullMethodName:       grpcPublic,
+	chirpypb.ChirpyService_Refresh_FullMethodName:     grpcPublic,
+	chirpypb.ChirpyService_CreateChirp_FullMethodName: grpcRequired,
+	chirpypb.ChirpyService_ListChirps_FullMethodName:  grpcOptional,
+	chirpypb.ChirpyService_DeleteChirp_FullMethodName: grpcRequired,
+}
+
+// newGRPCServer returns the gRPC server, using the HTTP server's
+// certificate when it has one on disk.
+func (cfg *apiConfig) newGRPCServer(c config.Server) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(grpcLogUnary, cfg.grpcAuthUnary),
+		grpc.ChainStreamInterceptor(grpcLogStream, cfg.grpcAuthStream),
+	}
+	if c.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	server := grpc.NewServer(opts...)
+	chirpypb.RegisterChirpyServiceServer(server, &grpcService{cfg: cfg})
+	return server, nil
+}
+
+// runGRPCServer serves on lis until ctx is cancelled, then gives in-flight
+// calls up to the shutdown timeout to finish.
+func runGRPCServer(ctx context.Context, c config.Server, server *grpc.Server, lis net.Listener) error {
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("grpc server listening", "addr", lis.Addr().String(), "tls", c.TLSCertFile != "")
+		errs <- server.Serve(lis)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(c.ShutdownTimeout):
+		server.Stop()
+	}
+	return nil
+}
+
+type grpcUserKey struct{}
+
+// grpcUser returns the caller authenticated by the interceptors.
+func grpcUser(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(grpcUserKey{}).(uuid.UUID)
+	return userID, ok
+}
+
+// grpcAuthenticate checks the access token in ctx's metadata against what
+// method needs and adds the user to the context. Like
+// middlewareAccountStatus, it refuses banned and deleted users everything
+// and suspended users anything that changes something.
+func (cfg *apiConfig) grpcAuthenticate(ctx context.Context, method string) (context.Context, error) {
+	need := grpcMethodAuth[method]
+	if need == grpcPublic {
+		return ctx, nil
+	}
+	var header string
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		header = values[0]
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		if need == grpcOptional && header == "" {
+			return ctx, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "not authorized")
+	}
+	userID, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	restriction, err := cfg.denylist.Check(ctx, userID)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if restriction != nil && (!restriction.ReadOnly() || need == grpcRequired) {
+		return nil, grpcError(ctx, apierror.Wrap(403, restriction))
+	}
+	return context.WithValue(ctx, grpcUserKey{}, userID), nil
+}
+
+func (cfg *apiConfig) grpcAuthUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := cfg.grpcAuthenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (cfg *apiConfig) grpcAuthStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := cfg.grpcAuthenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &grpcContextStream{ss, ctx})
+}
+
+// grpcContextStream replaces a stream's context.
+type grpcContextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *grpcContextStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcLogUnary and grpcLogStream log every call, as logging.Middleware
+// does for HTTP requests.
+func grpcLogUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	logGRPCCall(ctx, info.FullMethod, start, err)
+	return res, err
+}
+
+func grpcLogStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logGRPCCall(ss.Context(), info.FullMethod, start, err)
+	return err
+}
+
+func logGRPCCall(ctx context.Context, method string, start time.Time, err error) {
+	attrs := []any{"method", method, "code", status.Code(err).String(), "latency", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	slog.InfoContext(ctx, "grpc call", attrs...)
+}
+
+var grpcCodes = map[int]codes.Code{
+	400: codes.InvalidArgument,
+	401: codes.Unauthenticated,
+	403: codes.PermissionDenied,
+	404: codes.NotFound,
+	409: codes.AlreadyExists,
+	410: codes.NotFound,
+	413: codes.ResourceExhausted,
+	422: codes.InvalidArgument,
+	429: codes.ResourceExhausted,
+	503: codes.Unavailable,
+}
+
+// grpcError converts an error from the services to a gRPC status, as
+// errorResponse does for HTTP. Internal errors are logged and their
+// message withheld.
+func grpcError(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	p := apierror.ProblemFor(err)
+	code, ok := grpcCodes[p.Status]
+	if !ok {
+		code = codes.Internal
+	}
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	if p.Status >= 500 {
+		slog.ErrorContext(ctx, "grpc call failed", "error", err)
+	}
+	st := status.New(code, msg)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(p.Code), Domain: "chirpy"}}
+	if len(p.Errors) > 0 {
+		bad := &errdetails.BadRequest{}
+		for _, f := range p.Errors {
+			bad.FieldViolations = append(bad.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+				Reason:      f.Code,
+			})
+		}
+		details = append(details, bad)
+	}
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcService implements the gRPC API with the services the REST handlers
+// use.
+type grpcService struct {
+	chirpypb.UnimplementedChirpyServiceServer
+	cfg *apiConfig
+}
+
+func grpcParseID(name, id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, apierror.InvalidID(name, err)
+	}
+	return parsed, nil
+}
+
+func userProto(u User) *chirpypb.User {
+	return &chirpypb.User{
+		Id:          u.ID.String(),
+		CreatedAt:   timestamppb.New(u.CreatedAt),
+		UpdatedAt:   timestamppb.New(u.UpdatedAt),
+		Email:       u.Email,
+		IsChirpyRed: u.IsChirpyRed,
+	}
+}
+
+func chirpProto(c database.Chirp) *chirpypb.Chirp {
+	return &chirpypb.Chirp{
+		Id:        c.ID.String(),
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+		Body:      c.Body,
+		UserId:    c.UserID.String(),
+	}
+}
+
+func (s *grpcService) CreateUser(ctx context.Context, req *chirpypb.CreateUserRequest) (*chirpypb.CreateUserResponse, error) {
+	row, err := s.cfg.users.Create(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &chirpypb.CreateUserResponse{User: userProto(createUserRow(row).User())}, nil
+}
+
+func (s *grpcService) Login(ctx context.Context, req *chirpypb.LoginRequest) (*chirpypb.LoginResponse, error) {
+	var client sessions.Client
+	if values := metadata.ValueFromIncomingContext(ctx, "user-agent"); len(values) > 0 {
+		client.UserAgent = values[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		client.RemoteAddr = p.Addr.String()
+	}
+	session, err := s.cfg.sessions.Login(ctx, req.GetEmail(), req.GetPassword(), client)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &chirpypb.LoginResponse{
+		User:         userProto(sessionUser(session)),
+		AccessToken:  session.AccessToken,
+		RefreshToken: session.RefreshToken,
+	}, nil
+}
+
+func (s *grpcService) Refresh(ctx context.Context, req *chirpypb.RefreshRequest) (*chirpypb.RefreshResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.Unauthenticated, "refresh_token is required")
+	}
+	token, err := s.cfg.sessions.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &chirpypb.RefreshResponse{AccessToken: token}, nil
+}
+
+func (s *grpcService) CreateChirp(ctx context.Context, req *chirpypb.CreateChirpRequest) (*chirpypb.CreateChirpResponse, error) {
+	userID, _ := grpcUser(ctx)
+	body, err := s.cfg.chirps.Prepare(ctx, userID, req.GetBody())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	chirp, err := s.cfg.chirps.Add(ctx, userID, body)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &chirpypb.CreateChirpResponse{Chirp: chirpProto(chirp)}, nil
+}
+
+func (s *grpcService) ListChirps(req *chirpypb.ListChirpsRequest, stream grpc.ServerStreamingServer[chirpypb.ListChirpsResponse]) error {
+	ctx := stream.Context()
+	var viewer, authorID uuid.NullUUID
+	viewer.UUID, viewer.Valid = grpcUser(ctx)
+	if req.GetAuthorId() != "" {
+		id, err := grpcParseID("author_id", req.GetAuthorId())
+		if err != nil {
+			return grpcError(ctx, err)
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	list, err := s.cfg.chirps.List(ctx, viewer, authorID, req.GetNewestFirst())
+	if err != nil {
+		return grpcError(ctx, err)
+	}
+	for _, c := range list {
+		if err := stream.Send(&chirpypb.ListChirpsResponse{Chirp: chirpProto(c)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *grpcService) DeleteChirp(ctx context.Context, req *chirpypb.DeleteChirpRequest) (*chirpypb.DeleteChirpResponse, error) {
+	userID, _ := grpcUser(ctx)
+	chirpID, err := grpcParseID("chirp ID", req.GetId())
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if err := s.cfg.chirps.Delete(ctx, userID, chirpID); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &chirpypb.DeleteChirpResponse{}, nil
+}
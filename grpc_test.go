@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/chirpypb"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type restrictedUsers []database.ListRestrictedUsersRow
+
+func (r restrictedUsers) ListRestrictedUsers(ctx context.Context) ([]database.ListRestrictedUsersRow, error) {
+	return r, nil
+}
+
+// newGRPCClient serves cfg's gRPC API over an in-memory connection.
+func newGRPCClient(t *testing.T, cfg *apiConfig) chirpypb.ChirpyServiceClient {
+	t.Helper()
+	server, err := cfg.newGRPCServer(config.Server{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return chirpypb.NewChirpyServiceClient(conn)
+}
+
+func withToken(t *testing.T, userID uuid.UUID) context.Context {
+	t.Helper()
+	token, err := auth.MakeJWT(userID, testTokenSecret, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func errorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// TestGRPCMethodAuth checks that every method says what it needs from the
+// caller, rather than falling back to requiring a token.
+func TestGRPCMethodAuth(t *testing.T) {
+	desc := chirpypb.ChirpyService_ServiceDesc
+	var methods []string
+	for _, m := range desc.Methods {
+		methods = append(methods, m.MethodName)
+	}
+	for _, s := range desc.Streams {
+		methods = append(methods, s.StreamName)
+	}
+	for _, m := range methods {
+		if _, ok := grpcMethodAuth["/"+desc.ServiceName+"/"+m]; !ok {
+			t.Errorf("%s is missing from grpcMethodAuth", m)
+		}
+	}
+	if len(grpcMethodAuth) != len(methods) {
+		t.Errorf("grpcMethodAuth has %d methods, the service %d", len(grpcMethodAuth), len(methods))
+	}
+}
+
+// TestGRPCRefusals covers what's refused before the database is used.
+func TestGRPCRefusals(t *testing.T) {
+	suspended, banned, active := uuid.New(), uuid.New(), uuid.New()
+	cfg := &apiConfig{
+		tokenSecret: testTokenSecret,
+		denylist: denylist.New(restrictedUsers{
+			{ID: suspended, Status: "suspended", SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}},
+			{ID: banned, Status: "banned"},
+		}, 0),
+	}
+	cfg.initServices()
+	client := newGRPCClient(t, cfg)
+	ctx := context.Background()
+
+	_, err := client.CreateUser(ctx, &chirpypb.CreateUserRequest{Email: "walt", Password: "short"})
+	if status.Code(err) != codes.InvalidArgument || errorReason(err) != "validation_failed" {
+		t.Errorf("CreateUser with a bad email and password: err = %v", err)
+	}
+	var fields []string
+	for _, d := range status.Convert(err).Details() {
+		if bad, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range bad.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	if strings.Join(fields, ",") != "email,password" {
+		t.Errorf("field violations = %v, want email and password", fields)
+	}
+
+	if _, err := client.CreateChirp(ctx, &chirpypb.CreateChirpRequest{Body: "hello"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("CreateChirp without a token: err = %v", err)
+	}
+	badToken := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer nonsense")
+	if _, err := client.DeleteChirp(badToken, &chirpypb.DeleteChirpRequest{Id: uuid.NewString()}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("DeleteChirp with an invalid token: err = %v", err)
+	}
+	_, err = client.CreateChirp(withToken(t, active), &chirpypb.CreateChirpRequest{Body: strings.Repeat("x", 141)})
+	if status.Code(err) != codes.InvalidArgument || errorReason(err) != "chirp_too_long" {
+		t.Errorf("CreateChirp with 141 characters: err = %v", err)
+	}
+	_, err = client.CreateChirp(withToken(t, suspended), &chirpypb.CreateChirpRequest{Body: "hello"})
+	if status.Code(err) != codes.PermissionDenied || errorReason(err) != "account_suspended" {
+		t.Errorf("CreateChirp while suspended: err = %v", err)
+	}
+	stream, err := client.ListChirps(withToken(t, banned), &chirpypb.ListChirpsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied || errorReason(err) != "account_banned" {
+		t.Errorf("ListChirps while banned: err = %v", err)
+	}
+	if _, err := client.Refresh(ctx, &chirpypb.RefreshRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Refresh without a token: err = %v", err)
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	client := newGRPCClient(t, newTestConfig(t))
+	ctx := context.Background()
+	email := "skyler-" + uuid.NewString()[:8] + "@breakingbad.com"
+
+	created, err := client.CreateUser(ctx, &chirpypb.CreateUserRequest{Email: email, Password: "carwash123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Login(ctx, &chirpypb.LoginRequest{Email: email, Password: "wrong password 1"}); status.Code(err) != codes.Unauthenticated || errorReason(err) != "invalid_credentials" {
+		t.Errorf("Login with the wrong password: err = %v", err)
+	}
+	login, err := client.Login(ctx, &chirpypb.LoginRequest{Email: email, Password: "carwash123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.GetUser().GetId() != created.GetUser().GetId() || login.GetAccessToken() == "" {
+		t.Fatalf("Login = %v", login)
+	}
+	refreshed, err := client.Refresh(ctx, &chirpypb.RefreshRequest{RefreshToken: login.GetRefreshToken()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+refreshed.GetAccessToken())
+
+	var ids []string
+	for _, body := range []string{"first", "second"} {
+		res, err := client.CreateChirp(authed, &chirpypb.CreateChirpRequest{Body: body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, res.GetChirp().GetId())
+	}
+	stream, err := client.ListChirps(ctx, &chirpypb.ListChirpsRequest{AuthorId: created.GetUser().GetId(), NewestFirst: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		listed = append(listed, res.GetChirp().GetId())
+	}
+	if len(listed) != 2 || listed[0] != ids[1] || listed[1] != ids[0] {
+		t.Errorf("ListChirps = %v, want %v newest first", listed, ids)
+	}
+
+	if _, err := client.DeleteChirp(withToken(t, uuid.New()), &chirpypb.DeleteChirpRequest{Id: ids[0]}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("DeleteChirp by another user: err = %v", err)
+	}
+	if _, err := client.DeleteChirp(authed, &chirpypb.DeleteChirpRequest{Id: ids[0]}); err != nil {
+		t.Errorf("DeleteChirp: %v", err)
+	}
+	if _, err := client.DeleteChirp(authed, &chirpypb.DeleteChirpRequest{Id: ids[0]}); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteChirp twice: err = %v", err)
+	}
+}
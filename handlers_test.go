@@ -0,0 +1,867 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/scheduler"
+	"github.com/google/uuid"
+)
+
+// The tests below drive the handlers with plain HTTP requests, each against
+// a database of its own, so they can count on exactly what they created.
+
+type testChirp struct {
+	ID     uuid.UUID `json:"id"`
+	Body   string    `json:"body"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// apiRequest sends body as JSON, with authorization as the Authorization
+// header if it isn't empty, and decodes a JSON response into out if it isn't
+// nil. It returns the response's status.
+func apiRequest(t *testing.T, srv *httptest.Server, method, path, authorization string, body, out any) int {
+	t.Helper()
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, srv.URL+path, reqBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding the response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func bearer(token string) string {
+	return "Bearer " + token
+}
+
+// signUp creates a user and logs them in.
+func signUp(t *testing.T, srv *httptest.Server, email, password string) User {
+	t.Helper()
+	creds := map[string]string{"email": email, "password": password}
+	if status := apiRequest(t, srv, "POST", "/api/users", "", creds, nil); status != 201 {
+		t.Fatalf("signing up %s: status %d", email, status)
+	}
+	return signIn(t, srv, email, password)
+}
+
+func signIn(t *testing.T, srv *httptest.Server, email, password string) User {
+	t.Helper()
+	var user User
+	creds := map[string]string{"email": email, "password": password}
+	if status := apiRequest(t, srv, "POST", "/api/login", "", creds, &user); status != 200 {
+		t.Fatalf("logging in %s: status %d", email, status)
+	}
+	return user
+}
+
+func postChirp(t *testing.T, srv *httptest.Server, token, body string) testChirp {
+	t.Helper()
+	var chirp testChirp
+	if status := apiRequest(t, srv, "POST", "/api/chirps", bearer(token), map[string]string{"body": body}, &chirp); status != 201 {
+		t.Fatalf("posting %q: status %d", body, status)
+	}
+	return chirp
+}
+
+func TestSignupAndLogin(t *testing.T) {
+	srv := newTestServer(t)
+	creds := map[string]string{"email": "walt@breakingbad.com", "password": "heisenberg1"}
+
+	if status := apiRequest(t, srv, "POST", "/api/users", "", map[string]string{"email": "walt"}, nil); status != 422 {
+		t.Errorf("signing up without a password: status %d, want 422", status)
+	}
+	var created map[string]any
+	if status := apiRequest(t, srv, "POST", "/api/users", "", creds, &created); status != 201 {
+		t.Fatalf("signing up: status %d, want 201", status)
+	}
+	if created["email"] != creds["email"] || created["is_chirpy_red"] != false {
+		t.Errorf("signing up returned %v", created)
+	}
+	if _, ok := created["password"]; ok {
+		t.Error("signing up returned the password")
+	}
+	if status := apiRequest(t, srv, "POST", "/api/users", "", creds, nil); status != 409 {
+		t.Errorf("signing up with a taken email: status %d, want 409", status)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		want     int
+	}{
+		{"wrong password", "walt@breakingbad.com", "heisenberg2", 401},
+		{"unknown email", "jesse@breakingbad.com", "heisenberg1", 401},
+		{"missing password", "walt@breakingbad.com", "", 422},
+		{"ok", "walt@breakingbad.com", "heisenberg1", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user User
+			status := apiRequest(t, srv, "POST", "/api/login", "", map[string]string{"email": tt.email, "password": tt.password}, &user)
+			if status != tt.want {
+				t.Fatalf("status %d, want %d", status, tt.want)
+			}
+			if status == 200 && (user.ID.String() != created["id"] || user.Token == "" || user.RefreshToken == "") {
+				t.Errorf("logged in as %+v", user)
+			}
+		})
+	}
+
+	user := signUp(t, srv, "skyler@breakingbad.com", "carwash123")
+	update := map[string]string{"email": "skyler.white@breakingbad.com", "password": "carwash456"}
+	if status := apiRequest(t, srv, "PUT", "/api/users", "", update, nil); status != 401 {
+		t.Errorf("updating without a token: status %d, want 401", status)
+	}
+	var updated User
+	if status := apiRequest(t, srv, "PUT", "/api/users", bearer(user.Token), update, &updated); status != 200 || updated.Email != update["email"] {
+		t.Fatalf("updating: status %d, %+v", status, updated)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/login", "", update, nil); status != 200 {
+		t.Errorf("logging in with the new details: status %d", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/login", "", map[string]string{"email": "skyler@breakingbad.com", "password": "carwash123"}, nil); status != 401 {
+		t.Errorf("logging in with the old details: status %d, want 401", status)
+	}
+	taken := map[string]string{"email": "walt@breakingbad.com", "password": "carwash456"}
+	if status := apiRequest(t, srv, "PUT", "/api/users", bearer(user.Token), taken, nil); status != 409 {
+		t.Errorf("updating to a taken email: status %d, want 409", status)
+	}
+}
+
+func TestRefreshAndRevoke(t *testing.T) {
+	srv := newTestServer(t)
+	user := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+
+	if status := apiRequest(t, srv, "POST", "/api/refresh", "", nil, nil); status != 401 {
+		t.Errorf("refreshing without a token: status %d, want 401", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/refresh", bearer(user.Token), nil, nil); status != 401 {
+		t.Errorf("refreshing with an access token: status %d, want 401", status)
+	}
+	var refreshed struct {
+		Token string `json:"token"`
+	}
+	if status := apiRequest(t, srv, "POST", "/api/refresh", bearer(user.RefreshToken), nil, &refreshed); status != 200 {
+		t.Fatalf("refreshing: status %d", status)
+	}
+	postChirp(t, srv, refreshed.Token, "posted with a refreshed token")
+
+	if status := apiRequest(t, srv, "POST", "/api/revoke", bearer(user.RefreshToken), nil, nil); status != 204 {
+		t.Fatalf("revoking: status %d, want 204", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/refresh", bearer(user.RefreshToken), nil, nil); status != 401 {
+		t.Errorf("refreshing a revoked token: status %d, want 401", status)
+	}
+}
+
+func TestChirpOwnership(t *testing.T) {
+	srv := newTestServer(t)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+
+	if status := apiRequest(t, srv, "POST", "/api/chirps", "", map[string]string{"body": "anonymous"}, nil); status != 401 {
+		t.Errorf("posting without a token: status %d, want 401", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/chirps", bearer(walt.Token), map[string]string{"body": strings.Repeat("x", 141)}, nil); status != 400 {
+		t.Errorf("posting 141 characters: status %d, want 400", status)
+	}
+	chirp := postChirp(t, srv, walt.Token, "what a Kerfuffle")
+	if chirp.Body != "what a ****" || chirp.UserID != walt.ID {
+		t.Errorf("posted %+v", chirp)
+	}
+
+	var got testChirp
+	if status := apiRequest(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, &got); status != 200 || got != chirp {
+		t.Errorf("getting the chirp: status %d, %+v", status, got)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps/not-an-id", "", nil, nil); status != 400 {
+		t.Errorf("getting an invalid ID: status %d, want 400", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps/"+uuid.NewString(), "", nil, nil); status != 404 {
+		t.Errorf("getting a missing chirp: status %d, want 404", status)
+	}
+
+	path := "/api/chirps/" + chirp.ID.String()
+	if status := apiRequest(t, srv, "DELETE", path, "", nil, nil); status != 401 {
+		t.Errorf("deleting without a token: status %d, want 401", status)
+	}
+	if status := apiRequest(t, srv, "DELETE", path, bearer(jesse.Token), nil, nil); status != 403 {
+		t.Errorf("deleting another user's chirp: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "DELETE", path, bearer(walt.Token), nil, nil); status != 204 {
+		t.Fatalf("deleting: status %d, want 204", status)
+	}
+	if status := apiRequest(t, srv, "GET", path, "", nil, nil); status != 404 {
+		t.Errorf("getting a deleted chirp: status %d, want 404", status)
+	}
+	if status := apiRequest(t, srv, "DELETE", path, bearer(walt.Token), nil, nil); status != 404 {
+		t.Errorf("deleting twice: status %d, want 404", status)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	srv := newTestServer(t)
+	user := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	chirp := postChirp(t, srv, user.Token, "say my name")
+
+	if status := apiRequest(t, srv, "DELETE", "/api/users/me", bearer(user.Token), nil, nil); status != 202 {
+		t.Fatalf("deleting the account: status %d, want 202", status)
+	}
+	// The access token outlives the deletion, but is refused straight away.
+	if status := apiRequest(t, srv, "POST", "/api/chirps", bearer(user.Token), map[string]string{"body": "still here"}, nil); status != 403 {
+		t.Errorf("posting after deleting the account: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps", bearer(user.Token), nil, nil); status != 403 {
+		t.Errorf("reading after deleting the account: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/refresh", bearer(user.RefreshToken), nil, nil); status != 401 {
+		t.Errorf("refreshing after deleting the account: status %d, want 401", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, nil); status != 404 {
+		t.Errorf("getting a chirp of the deleted account: status %d, want 404", status)
+	}
+
+	// Logging back in cancels the deletion.
+	user = signIn(t, srv, user.Email, "heisenberg1")
+	postChirp(t, srv, user.Token, "I am the one who knocks")
+	if status := apiRequest(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, nil); status != 200 {
+		t.Errorf("getting a chirp after the deletion was cancelled: status %d, want 200", status)
+	}
+}
+
+func TestListChirps(t *testing.T) {
+	srv := newTestServer(t)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+	first := postChirp(t, srv, walt.Token, "first")
+	second := postChirp(t, srv, jesse.Token, "second")
+	third := postChirp(t, srv, walt.Token, "third")
+
+	tests := []struct {
+		name  string
+		query string
+		want  []testChirp
+	}{
+		{"all", "", []testChirp{first, second, third}},
+		{"newest first", "?sort=desc", []testChirp{third, second, first}},
+		{"oldest first", "?sort=asc", []testChirp{first, second, third}},
+		{"by author", "?author_id=" + walt.ID.String(), []testChirp{first, third}},
+		{"by author, newest first", "?author_id=" + walt.ID.String() + "&sort=desc", []testChirp{third, first}},
+		{"by an author with none", "?author_id=" + uuid.NewString(), []testChirp{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []testChirp
+			if status := apiRequest(t, srv, "GET", "/api/chirps"+tt.query, "", nil, &got); status != 200 {
+				t.Fatalf("status %d", status)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps?author_id=walt", "", nil, nil); status != 400 {
+		t.Errorf("listing by an invalid author ID: status %d, want 400", status)
+	}
+	// Reading doesn't need a token, so a bad one is ignored.
+	for _, path := range []string{"/api/chirps", "/api/chirps/" + first.ID.String()} {
+		if status := apiRequest(t, srv, "GET", path, bearer("stale"), nil, nil); status != 200 {
+			t.Errorf("GET %s with an invalid token: status %d, want 200", path, status)
+		}
+	}
+}
+
+func TestPolkaWebhook(t *testing.T) {
+	srv := newTestServer(t)
+	user := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	event := func(name string) map[string]any {
+		return map[string]any{"event": name, "data": map[string]any{"user_id": user.ID}}
+	}
+	isChirpyRed := func() bool {
+		t.Helper()
+		var u User
+		if status := apiRequest(t, srv, "POST", "/api/login", "", map[string]string{"email": user.Email, "password": "heisenberg1"}, &u); status != 200 {
+			t.Fatalf("logging in: status %d", status)
+		}
+		return u.IsChirpyRed
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		event         string
+		want          int
+	}{
+		{"no key", "", "user.upgraded", 401},
+		{"wrong key", "ApiKey nope", "user.upgraded", 401},
+		{"bearer token", bearer(user.Token), "user.upgraded", 401},
+		{"other event", "ApiKey " + testPolkaKey, "user.payment_failed", 204},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := apiRequest(t, srv, "POST", "/api/polka/webhooks", tt.authorization, event(tt.event), nil); status != tt.want {
+				t.Errorf("status %d, want %d", status, tt.want)
+			}
+			if isChirpyRed() {
+				t.Error("the user was upgraded")
+			}
+		})
+	}
+
+	upgraded, notFound := webhookCount(t, metrics.WebhookUpgraded), webhookCount(t, metrics.WebhookNotFound)
+	if status := apiRequest(t, srv, "POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, event("user.upgraded"), nil); status != 204 {
+		t.Fatalf("upgrading: status %d, want 204", status)
+	}
+	if !isChirpyRed() {
+		t.Error("the user wasn't upgraded")
+	}
+
+	unknown := map[string]any{"event": "user.upgraded", "data": map[string]any{"user_id": uuid.New()}}
+	if status := apiRequest(t, srv, "POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, unknown, nil); status != 404 {
+		t.Errorf("upgrading an unknown user: status %d, want 404", status)
+	}
+	if got := webhookCount(t, metrics.WebhookUpgraded) - upgraded; got != 1 {
+		t.Errorf("%v webhooks counted as upgraded, want 1", got)
+	}
+	if got := webhookCount(t, metrics.WebhookNotFound) - notFound; got != 1 {
+		t.Errorf("%v webhooks counted as not found, want 1", got)
+	}
+}
+
+// webhookCount reads the user.upgraded webhooks counted with outcome. The
+// registry is shared by every test, so callers compare counts before and
+// after.
+func webhookCount(t *testing.T, outcome string) float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "chirpy_polka_webhooks_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["event"] == "user.upgraded" && labels["outcome"] == outcome {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// makeAdmin gives the user access to the admin API, which only the CLI can.
+func makeAdmin(t *testing.T, cfg *apiConfig, userID uuid.UUID) {
+	t.Helper()
+	if err := cfg.dbQueries.SetUserAdmin(context.Background(), database.SetUserAdminParams{IsAdmin: true, ID: userID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAdminAPI(t *testing.T) {
+	cfg := newTestConfig(t)
+	srv := serveTestConfig(t, cfg)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+	hank := signUp(t, srv, "hank@dea.gov", "minerals123")
+	gus := signUp(t, srv, "gus_fring@pollos.com", "chicken123")
+	makeAdmin(t, cfg, walt.ID)
+	if _, err := cfg.dbQueries.UpgradeUserToChirpyRed(context.Background(), hank.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := apiRequest(t, srv, "GET", "/admin/api/users", "", nil, nil); status != 401 {
+		t.Errorf("searching anonymously: status %d, want 401", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/admin/api/users", bearer(jesse.Token), nil, nil); status != 403 {
+		t.Errorf("searching as a user: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/admin/api/users/"+jesse.ID.String(), bearer(jesse.Token), nil, nil); status != 403 {
+		t.Errorf("getting a user as a user: status %d, want 403", status)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []uuid.UUID
+	}{
+		{"everyone", "", []uuid.UUID{walt.ID, jesse.ID, hank.ID, gus.ID}},
+		{"part of the email", "?q=JESSE", []uuid.UUID{jesse.ID}},
+		{"underscore is literal", "?q=_", []uuid.UUID{gus.ID}},
+		{"percent is literal", "?q=%25", nil},
+		{"domain", "?domain=DEA.gov", []uuid.UUID{hank.ID}},
+		{"domain with @", "?domain=@breakingbad.com", []uuid.UUID{walt.ID, jesse.ID}},
+		{"domain is whole", "?domain=dea", nil},
+		{"domain wildcard", "?domain=%25", nil},
+		{"chirpy red", "?chirpy_red=true", []uuid.UUID{hank.ID}},
+		{"created after", "?created_after=2999-01-01", nil},
+		{"paged", "?limit=1&offset=1", []uuid.UUID{jesse.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res struct {
+				Users []AdminUser `json:"users"`
+			}
+			if status := apiRequest(t, srv, "GET", "/admin/api/users"+tt.query, bearer(walt.Token), nil, &res); status != 200 {
+				t.Fatalf("status %d, want 200", status)
+			}
+			var got []uuid.UUID
+			for _, u := range res.Users {
+				got = append(got, u.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if status := apiRequest(t, srv, "GET", "/admin/api/users?chirpy_red=maybe", bearer(walt.Token), nil, nil); status != 400 {
+		t.Errorf("searching with an invalid filter: status %d, want 400", status)
+	}
+
+	postChirp(t, srv, jesse.Token, "yeah science")
+	deleted := postChirp(t, srv, jesse.Token, "yeah magnets")
+	if status := apiRequest(t, srv, "DELETE", "/api/chirps/"+deleted.ID.String(), bearer(jesse.Token), nil, nil); status != 204 {
+		t.Fatalf("deleting a chirp: status %d", status)
+	}
+	signIn(t, srv, jesse.Email, "yeahscience1")
+	var detail AdminUserDetail
+	if status := apiRequest(t, srv, "GET", "/admin/api/users/"+jesse.ID.String(), bearer(walt.Token), nil, &detail); status != 200 {
+		t.Fatalf("getting a user: status %d, want 200", status)
+	}
+	if detail.Email != jesse.Email || detail.Status != "active" || detail.Chirps != 1 || detail.ActiveSessions != 2 {
+		t.Errorf("got %+v, want 1 chirp and 2 sessions", detail)
+	}
+	if status := apiRequest(t, srv, "GET", "/admin/api/users/"+uuid.NewString(), bearer(walt.Token), nil, nil); status != 404 {
+		t.Errorf("getting a missing user: status %d, want 404", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/admin/api/users/not-an-id", bearer(walt.Token), nil, nil); status != 400 {
+		t.Errorf("getting an invalid ID: status %d, want 400", status)
+	}
+}
+
+func TestAccountStatus(t *testing.T) {
+	cfg := newTestConfig(t)
+	// A denylist that would go stale for an hour, so that changes only show
+	// up straight away if the handlers invalidate it.
+	cfg.denylist = denylist.New(cfg.dbQueries, time.Hour)
+	srv := serveTestConfig(t, cfg)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+	makeAdmin(t, cfg, walt.ID)
+	jessePath := "/admin/api/users/" + jesse.ID.String()
+	setStatus := func(method, action string, body any) {
+		t.Helper()
+		if status := apiRequest(t, srv, method, jessePath+"/"+action, bearer(walt.Token), body, nil); status != 204 {
+			t.Fatalf("%s %s: status %d, want 204", method, action, status)
+		}
+	}
+
+	if status := apiRequest(t, srv, "POST", jessePath+"/suspend", bearer(jesse.Token), nil, nil); status != 403 {
+		t.Errorf("suspending as a user: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "POST", jessePath+"/suspend", bearer(walt.Token), map[string]any{"until": time.Now().Add(-time.Hour)}, nil); status != 422 {
+		t.Errorf("suspending until the past: status %d, want 422", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/admin/api/users/"+uuid.NewString()+"/ban", bearer(walt.Token), nil, nil); status != 404 {
+		t.Errorf("banning a missing user: status %d, want 404", status)
+	}
+
+	setStatus("POST", "suspend", map[string]any{"until": time.Now().Add(time.Hour), "reason": "cooking"})
+	if status := apiRequest(t, srv, "POST", "/api/chirps", bearer(jesse.Token), map[string]string{"body": "yeah science"}, nil); status != 403 {
+		t.Errorf("posting while suspended: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps", bearer(jesse.Token), nil, nil); status != 200 {
+		t.Errorf("reading while suspended: status %d, want 200", status)
+	}
+	// Every GraphQL request is a POST, but only mutations are refused.
+	for _, endpoint := range []string{srv.URL + "/api/graphql", srv.URL + "/api/v1/graphql"} {
+		if status, res := postGraphQL(t, endpoint, jesse.Token, `{ viewer { email } }`); status != 200 || len(res.Errors) > 0 {
+			t.Errorf("querying %s while suspended: status %d, %+v", endpoint, status, res.Errors)
+		}
+		if status, _ := postGraphQL(t, endpoint, jesse.Token, `mutation { createChirp(body: "yeah science") { id } }`); status != 403 {
+			t.Errorf("mutating %s while suspended: status %d, want 403", endpoint, status)
+		}
+	}
+	if status := apiRequest(t, srv, "PUT", "/api/users", bearer(jesse.Token), map[string]string{"email": jesse.Email, "password": "yeahscience2"}, nil); status != 403 {
+		t.Errorf("updating while suspended: status %d, want 403", status)
+	}
+	var detail AdminUserDetail
+	if status := apiRequest(t, srv, "GET", jessePath, bearer(walt.Token), nil, &detail); status != 200 || detail.Status != "suspended" || detail.StatusReason != "cooking" {
+		t.Errorf("getting a suspended user: status %d, %+v", status, detail)
+	}
+
+	setStatus("POST", "ban", nil)
+	if status := apiRequest(t, srv, "GET", "/api/chirps", bearer(jesse.Token), nil, nil); status != 403 {
+		t.Errorf("reading while banned: status %d, want 403", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/refresh", bearer(jesse.RefreshToken), nil, nil); status != 401 {
+		t.Errorf("refreshing while banned: status %d, want 401", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/login", "", map[string]string{"email": jesse.Email, "password": "yeahscience1"}, nil); status != 403 {
+		t.Errorf("logging in while banned: status %d, want 403", status)
+	}
+
+	setStatus("DELETE", "ban", nil)
+	postChirp(t, srv, jesse.Token, "yeah science")
+	signIn(t, srv, jesse.Email, "yeahscience1")
+}
+
+func TestDirectConversation(t *testing.T) {
+	srv := newTestServer(t)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+
+	// Both sides start the conversation at once; they all get the same one.
+	const requests = 8
+	var (
+		wg       sync.WaitGroup
+		statuses [requests]int
+		ids      [requests]uuid.UUID
+	)
+	for i := range requests {
+		from, to := walt, jesse
+		if i%2 == 1 {
+			from, to = jesse, walt
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var conversation Conversation
+			statuses[i] = apiRequest(t, srv, "POST", "/api/conversations", bearer(from.Token), map[string]any{"member_ids": []uuid.UUID{to.ID}}, &conversation)
+			ids[i] = conversation.ID
+		}()
+	}
+	wg.Wait()
+	created := 0
+	for i, status := range statuses {
+		switch status {
+		case 201:
+			created++
+		case 200:
+		default:
+			t.Fatalf("starting a conversation: status %d", status)
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("conversation %d is %v, want %v", i, ids[i], ids[0])
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d conversations created, want 1", created)
+	}
+
+	if status := apiRequest(t, srv, "PUT", "/api/users/me/dm_allowlist", bearer(walt.Token), map[string]any{"add": []uuid.UUID{uuid.New()}}, nil); status != 404 {
+		t.Errorf("allowlisting an unknown user: status %d, want 404", status)
+	}
+}
+
+func TestBlocksAndMutes(t *testing.T) {
+	srv := newTestServer(t)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+	hank := signUp(t, srv, "hank@dea.gov", "minerals1")
+	waltsChirp := postChirp(t, srv, walt.Token, "say my name")
+	jessesChirp := postChirp(t, srv, jesse.Token, "yeah science")
+
+	// listed returns the IDs of the chirps the viewer is shown at path.
+	listed := func(viewer User, path string) []uuid.UUID {
+		t.Helper()
+		var chirps []testChirp
+		if status := apiRequest(t, srv, "GET", path, bearer(viewer.Token), nil, &chirps); status != 200 {
+			t.Fatalf("GET %s: status %d", path, status)
+		}
+		ids := make([]uuid.UUID, len(chirps))
+		for i, c := range chirps {
+			ids[i] = c.ID
+		}
+		return ids
+	}
+	// visible checks what hank is shown of the author's chirp: in the list,
+	// in the author's list and by ID.
+	visible := func(author User, chirp testChirp, inLists, byID bool) {
+		t.Helper()
+		if got := slices.Contains(listed(hank, "/api/chirps"), chirp.ID); got != inLists {
+			t.Errorf("%q listed: %v, want %v", chirp.Body, got, inLists)
+		}
+		if got := slices.Contains(listed(hank, "/api/chirps?author_id="+author.ID.String()), chirp.ID); got != inLists {
+			t.Errorf("%q listed by author: %v, want %v", chirp.Body, got, inLists)
+		}
+		want := 404
+		if byID {
+			want = 200
+		}
+		if status := apiRequest(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), bearer(hank.Token), nil, nil); status != want {
+			t.Errorf("getting %q: status %d, want %d", chirp.Body, status, want)
+		}
+	}
+	relationship := func(method string, actor, target User, action string) {
+		t.Helper()
+		path := "/api/users/" + target.ID.String() + "/" + action
+		if status := apiRequest(t, srv, method, path, bearer(actor.Token), nil, nil); status != 204 {
+			t.Fatalf("%s %s: status %d, want 204", method, path, status)
+		}
+	}
+	mention := "hey @WALT@breakingbad.com, call me"
+
+	relationship("POST", walt, hank, "block")
+	visible(walt, waltsChirp, false, false)
+	visible(jesse, jessesChirp, true, true)
+	if status := apiRequest(t, srv, "GET", "/api/chirps/"+waltsChirp.ID.String(), "", nil, nil); status != 200 {
+		t.Errorf("getting a chirp anonymously: status %d, want 200", status)
+	}
+	if status := apiRequest(t, srv, "POST", "/api/chirps", bearer(hank.Token), map[string]string{"body": mention}, nil); status != 403 {
+		t.Errorf("mentioning a user who blocked you: status %d, want 403", status)
+	}
+	relationship("DELETE", walt, hank, "block")
+	visible(walt, waltsChirp, true, true)
+	postChirp(t, srv, hank.Token, mention)
+
+	// Muting hides the author's chirps from lists, but they can still be
+	// fetched, and no one else is affected.
+	relationship("POST", hank, jesse, "mute")
+	visible(jesse, jessesChirp, false, true)
+	if !slices.Contains(listed(walt, "/api/chirps"), jessesChirp.ID) {
+		t.Error("a chirp muted by someone else isn't listed")
+	}
+	relationship("DELETE", hank, jesse, "mute")
+	visible(jesse, jessesChirp, true, true)
+
+	if status := apiRequest(t, srv, "POST", "/api/users/"+uuid.NewString()+"/block", bearer(hank.Token), nil, nil); status != 404 {
+		t.Errorf("blocking an unknown user: status %d, want 404", status)
+	}
+}
+
+// testUnpublishedChirp is a draft or scheduled chirp as its author sees it.
+type testUnpublishedChirp struct {
+	testChirp
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+func TestScheduledChirps(t *testing.T) {
+	cfg := newTestConfig(t)
+	srv := serveTestConfig(t, cfg)
+	ctx := context.Background()
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	hank := signUp(t, srv, "hank@dea.gov", "minerals1")
+
+	save := func(user User, req map[string]any, want int) testUnpublishedChirp {
+		t.Helper()
+		var chirp testUnpublishedChirp
+		if status := apiRequest(t, srv, "POST", "/api/chirps", bearer(user.Token), req, &chirp); status != want {
+			t.Fatalf("saving %v: status %d, want %d", req, status, want)
+		}
+		return chirp
+	}
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	draft := save(walt, map[string]any{"body": "a draft", "draft": true}, 201)
+	scheduled := save(walt, map[string]any{"body": "later", "publish_at": publishAt}, 201)
+	save(walt, map[string]any{"body": "too late", "publish_at": time.Now().Add(-time.Minute)}, 400)
+	if draft.Status != "draft" || draft.PublishAt != nil {
+		t.Errorf("saved draft %+v", draft)
+	}
+	if scheduled.Status != "scheduled" || scheduled.PublishAt == nil || !scheduled.PublishAt.Equal(publishAt) {
+		t.Errorf("scheduled %+v, want it at %v", scheduled, publishAt)
+	}
+
+	// Only the author sees unpublished chirps.
+	listScheduled := func(user User) []testUnpublishedChirp {
+		t.Helper()
+		var chirps []testUnpublishedChirp
+		if status := apiRequest(t, srv, "GET", "/api/chirps/scheduled", bearer(user.Token), nil, &chirps); status != 200 {
+			t.Fatalf("listing scheduled chirps: status %d", status)
+		}
+		return chirps
+	}
+	if got := listScheduled(walt); len(got) != 2 {
+		t.Errorf("Walt has %+v scheduled, want 2", got)
+	}
+	if got := listScheduled(hank); len(got) != 0 {
+		t.Errorf("Hank has %+v scheduled, want none", got)
+	}
+	var public []testChirp
+	apiRequest(t, srv, "GET", "/api/chirps", "", nil, &public)
+	if len(public) != 0 {
+		t.Errorf("unpublished chirps are listed: %+v", public)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/chirps/"+draft.ID.String(), bearer(hank.Token), nil, nil); status != 404 {
+		t.Errorf("getting someone else's draft: status %d, want 404", status)
+	}
+
+	publish := func(user User, id uuid.UUID, want int) {
+		t.Helper()
+		if status := apiRequest(t, srv, "POST", "/api/chirps/scheduled/"+id.String()+"/publish", bearer(user.Token), nil, nil); status != want {
+			t.Errorf("publishing %v: status %d, want %d", id, status, want)
+		}
+	}
+	publish(hank, scheduled.ID, 404)
+	publish(walt, scheduled.ID, 200)
+	publish(walt, scheduled.ID, 404)
+	if status := apiRequest(t, srv, "DELETE", "/api/chirps/scheduled/"+draft.ID.String(), bearer(hank.Token), nil, nil); status != 404 {
+		t.Errorf("deleting someone else's draft: status %d, want 404", status)
+	}
+	if status := apiRequest(t, srv, "DELETE", "/api/chirps/scheduled/"+draft.ID.String(), bearer(walt.Token), nil, nil); status != 204 {
+		t.Errorf("deleting a draft: status %d, want 204", status)
+	}
+	if got := listScheduled(walt); len(got) != 0 {
+		t.Errorf("Walt still has %+v scheduled", got)
+	}
+
+	// Mentions are checked again when a draft is published.
+	mention := save(hank, map[string]any{"body": "hi @walt@breakingbad.com", "draft": true}, 201)
+	if status := apiRequest(t, srv, "POST", "/api/users/"+hank.ID.String()+"/block", bearer(walt.Token), nil, nil); status != 204 {
+		t.Fatalf("blocking: status %d", status)
+	}
+	publish(hank, mention.ID, 403)
+
+	// Chirps the scheduler publishes end up like those published by hand.
+	due, err := cfg.dbQueries.AddUnpublishedChirp(ctx, database.AddUnpublishedChirpParams{
+		ChirpBody: "due",
+		UserID:    walt.ID,
+		Status:    "scheduled",
+		PublishAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduler.New(cfg.dbQueries, time.Hour, cfg.scheduledChirpPublished).PublishDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uuid.UUID{scheduled.ID, due.ID} {
+		chirp, err := cfg.dbQueries.GetChirpByID(ctx, database.GetChirpByIDParams{ID: id})
+		if err != nil {
+			t.Fatalf("getting published chirp %v: %v", id, err)
+		}
+		if chirp.Status != "published" || chirp.PublishAt.Valid {
+			t.Errorf("published chirp %+v, want no publish time left", chirp)
+		}
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	cfg := newTestConfig(t)
+	srv := serveTestConfig(t, cfg)
+	// Every login notifies: Walt gets three notifications, Jesse one.
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	signIn(t, srv, walt.Email, "heisenberg1")
+	signIn(t, srv, walt.Email, "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+	// Closing the notifier waits for them to have been written.
+	cfg.notifier.Close()
+
+	type page struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+		Limit         int            `json:"limit"`
+		Offset        int            `json:"offset"`
+	}
+	list := func(user User, query string) page {
+		t.Helper()
+		var p page
+		if status := apiRequest(t, srv, "GET", "/api/notifications"+query, bearer(user.Token), nil, &p); status != 200 {
+			t.Fatalf("listing %s's notifications%s: status %d", user.Email, query, status)
+		}
+		return p
+	}
+	read := func(user User, body any, want int) {
+		t.Helper()
+		if status := apiRequest(t, srv, "POST", "/api/notifications/read", bearer(user.Token), body, nil); status != want {
+			t.Fatalf("marking %v read: status %d, want %d", body, status, want)
+		}
+	}
+
+	if status := apiRequest(t, srv, "GET", "/api/notifications", "", nil, nil); status != 401 {
+		t.Errorf("listing anonymously: status %d, want 401", status)
+	}
+	all := list(walt, "")
+	if len(all.Notifications) != 3 || all.UnreadCount != 3 {
+		t.Fatalf("listed %d notifications, %d unread; want 3 and 3", len(all.Notifications), all.UnreadCount)
+	}
+	for _, n := range all.Notifications {
+		if n.Type != string(notify.TypeNewLogin) || n.Read || n.ReadAt != nil {
+			t.Errorf("notification %+v, want an unread login", n)
+		}
+	}
+
+	first, second := list(walt, "?limit=2"), list(walt, "?limit=2&offset=2")
+	if len(first.Notifications) != 2 || len(second.Notifications) != 1 || first.Limit != 2 || second.Offset != 2 {
+		t.Errorf("paged %+v then %+v, want 2 then 1", first, second)
+	} else if second.Notifications[0].ID != all.Notifications[2].ID {
+		t.Errorf("the second page starts at %s, want %s", second.Notifications[0].ID, all.Notifications[2].ID)
+	}
+	if status := apiRequest(t, srv, "GET", "/api/notifications?limit=0", bearer(walt.Token), nil, nil); status != 400 {
+		t.Errorf("listing with limit 0: status %d, want 400", status)
+	}
+
+	// Nobody can mark someone else's notifications read.
+	target := all.Notifications[0].ID
+	read(jesse, map[string]any{"ids": []uuid.UUID{target}}, 204)
+	if got := list(walt, ""); got.UnreadCount != 3 {
+		t.Errorf("%d unread after someone else marked one read, want 3", got.UnreadCount)
+	}
+	read(walt, map[string]any{"ids": []uuid.UUID{target}}, 204)
+	got := list(walt, "")
+	if got.UnreadCount != 2 {
+		t.Errorf("%d unread after marking one read, want 2", got.UnreadCount)
+	}
+	for _, n := range got.Notifications {
+		if wantRead := n.ID == target; n.Read != wantRead || (n.ReadAt != nil) != wantRead {
+			t.Errorf("notification %s read: %v, want %v", n.ID, n.Read, wantRead)
+		}
+	}
+
+	read(walt, map[string]any{}, 400)
+	read(walt, map[string]any{"all": true}, 204)
+	if got := list(walt, ""); got.UnreadCount != 0 {
+		t.Errorf("%d unread after marking all read, want 0", got.UnreadCount)
+	}
+	if got := list(jesse, ""); len(got.Notifications) != 1 || got.UnreadCount != 1 {
+		t.Errorf("Jesse has %d notifications, %d unread, after Walt read all theirs; want 1 and 1", len(got.Notifications), got.UnreadCount)
+	}
+}
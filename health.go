@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/health"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// livenessHandler answers as long as the process is serving requests.
+func livenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(200)
+	w.Write([]byte("OK"))
+}
+
+// worker is a background loop that records when it last ran.
+type worker interface {
+	LastRun() time.Time
+	Interval() time.Duration
+}
+
+// workerCheck fails when w hasn't run for a few of its intervals.
+func workerCheck(w worker) health.Check {
+	return func(ctx context.Context) error {
+		last := w.LastRun()
+		if last.IsZero() {
+			return fmt.Errorf("hasn't run yet")
+		}
+		if since := time.Since(last); since > 3*w.Interval() {
+			return fmt.Errorf("last ran %s ago", since.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+func pingCheck(db *sql.DB) health.Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
@@ -0,0 +1,185 @@
+// Package apierror describes the errors the API reports and writes them as
+// RFC 7807 problem details. Every error carries a stable, machine readable
+// code next to its HTTP status, so clients needn't parse messages.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Code identifies the kind of error. Codes are part of the API: once
+// published they don't change.
+type Code string
+
+const (
+	CodeBadRequest         Code = "bad_request"
+	CodeInvalidJSON        Code = "invalid_json"
+	CodeInvalidID          Code = "invalid_id"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeForbidden          Code = "forbidden"
+	CodeNotFound           Code = "not_found"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeConflict           Code = "conflict"
+	CodeGone               Code = "gone"
+	CodeRequestTooLarge    Code = "request_too_large"
+	CodeUnprocessable      Code = "unprocessable"
+	CodeValidation         Code = "validation_failed"
+	CodeTooManyRequests    Code = "too_many_requests"
+	CodeInternal           Code = "internal_error"
+	CodeUnavailable        Code = "unavailable"
+)
+
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusGone:                  CodeGone,
+	http.StatusRequestEntityTooLarge: CodeRequestTooLarge,
+	http.StatusUnprocessableEntity:   CodeUnprocessable,
+	http.StatusTooManyRequests:       CodeTooManyRequests,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// CodeForStatus is the code of errors reported with status that don't have
+// a more specific one.
+func CodeForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Coder is implemented by errors that know their own code, so packages can
+// define errors with codes without depending on this one.
+type Coder interface {
+	ErrorCode() string
+}
+
+// FieldError is a problem with one field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is an error reported to the client with Status and Code. Detail and
+// Fields are shown to the client; Err is the underlying cause, which is only
+// logged.
+type Error struct {
+	Status int
+	Code   Code
+	Detail string
+	Fields []FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Detail != "" && e.Err != nil:
+		return e.Detail + ": " + e.Err.Error()
+	case e.Detail != "":
+		return e.Detail
+	case e.Err != nil:
+		return e.Err.Error()
+	}
+	return http.StatusText(e.Status)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with a message for the client.
+func New(status int, code Code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Wrap reports err with status. Its message is shown to the client for
+// client errors, and hidden for server errors. Errors that are already an
+// *Error are returned as they are; a Coder's code is kept.
+func Wrap(status int, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	code := CodeForStatus(status)
+	var coder Coder
+	if errors.As(err, &coder) {
+		code = Code(coder.ErrorCode())
+	}
+	if status >= 500 {
+		return &Error{Status: status, Code: code, Err: err}
+	}
+	return &Error{Status: status, Code: code, Detail: err.Error(), Err: err}
+}
+
+// InvalidJSON reports a request body that couldn't be decoded. A body over
+// the server's size limit is reported as too large.
+func InvalidJSON(err error) *Error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeRequestTooLarge, Detail: "request body is too large", Err: err}
+	}
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidJSON, Detail: "invalid JSON body: " + err.Error(), Err: err}
+}
+
+// InvalidID reports a malformed ID in the path or query, named name.
+func InvalidID(name string, err error) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeInvalidID, Detail: "invalid " + name, Err: err}
+}
+
+// Problem is an RFC 7807 problem details object, extended with the error
+// code.
+type Problem struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+	Code   Code         `json:"code"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// ProblemFor describes err. Errors that aren't an *Error are internal errors
+// and their message is withheld.
+func ProblemFor(err error) Problem {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Err: err}
+	}
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.Status),
+		Status: e.Status,
+		Code:   e.Code,
+	}
+	if p.Code == "" {
+		p.Code = CodeForStatus(e.Status)
+	}
+	if e.Status < 500 {
+		p.Detail = e.Detail
+		p.Errors = e.Fields
+	}
+	return p
+}
+
+// Write writes err to w as problem details.
+func Write(w http.ResponseWriter, err error) {
+	p := ProblemFor(err)
+	data, _ := json.Marshal(p)
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(p.Status)
+	w.Write(data)
+}
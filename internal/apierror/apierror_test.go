@@ -0,0 +1,106 @@
+package apierror_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+)
+
+type codedError struct{}
+
+func (codedError) Error() string     { return "account is banned" }
+func (codedError) ErrorCode() string { return "account_banned" }
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   apierror.Code
+		wantDetail string
+	}{
+		{
+			name:       "client error",
+			err:        apierror.Wrap(404, errors.New("chirp not found")),
+			wantStatus: 404,
+			wantCode:   apierror.CodeNotFound,
+			wantDetail: "chirp not found",
+		},
+		{
+			name:       "server error hides its cause",
+			err:        apierror.Wrap(500, errors.New("pq: password authentication failed")),
+			wantStatus: 500,
+			wantCode:   apierror.CodeInternal,
+		},
+		{
+			name:       "plain error",
+			err:        errors.New("dial tcp: connection refused"),
+			wantStatus: 500,
+			wantCode:   apierror.CodeInternal,
+		},
+		{
+			name:       "coder",
+			err:        apierror.Wrap(403, codedError{}),
+			wantStatus: 403,
+			wantCode:   "account_banned",
+			wantDetail: "account is banned",
+		},
+		{
+			name:       "already wrapped",
+			err:        apierror.Wrap(401, apierror.InvalidID("chirp ID", errors.New("invalid UUID length: 3"))),
+			wantStatus: 400,
+			wantCode:   apierror.CodeInvalidID,
+			wantDetail: "invalid chirp ID",
+		},
+		{
+			name:       "body too large",
+			err:        apierror.InvalidJSON(&http.MaxBytesError{Limit: 10}),
+			wantStatus: 413,
+			wantCode:   apierror.CodeRequestTooLarge,
+			wantDetail: "request body is too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			apierror.Write(w, tt.err)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != apierror.ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, apierror.ContentType)
+			}
+			var p apierror.Problem
+			if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Status != tt.wantStatus || p.Code != tt.wantCode || p.Detail != tt.wantDetail {
+				t.Errorf("problem = %+v, want status %d, code %q, detail %q", p, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+			if p.Title != http.StatusText(tt.wantStatus) {
+				t.Errorf("title = %q, want %q", p.Title, http.StatusText(tt.wantStatus))
+			}
+			if tt.wantStatus >= 500 && strings.Contains(w.Body.String(), tt.err.Error()) {
+				t.Errorf("body %s leaks the internal error", w.Body)
+			}
+		})
+	}
+}
+
+func TestWriteFieldErrors(t *testing.T) {
+	fields := []apierror.FieldError{{Field: "email", Code: "invalid_email", Message: "must be an email address"}}
+	w := httptest.NewRecorder()
+	apierror.Write(w, &apierror.Error{Status: 422, Code: apierror.CodeValidation, Detail: "request is invalid", Fields: fields})
+	var p apierror.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Errors) != 1 || p.Errors[0] != fields[0] {
+		t.Errorf("errors = %+v, want %+v", p.Errors, fields)
+	}
+}
@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"context"
 	"errors"
 
 	"github.com/alexedwards/argon2id"
+	"go.opentelemetry.io/otel"
 )
 
+const tracerName = "github.com/Blustak/bootdev-chirpy/internal/auth"
+
 var Params = argon2id.Params{
 	Memory:     12 * 1024,
 	Iterations: 3,
@@ -15,6 +19,13 @@ var Params = argon2id.Params{
 }
 
 func HashPassword(password string) (string, error) {
+	return HashPasswordContext(context.Background(), password)
+}
+
+// HashPasswordContext is HashPassword, traced as a child of the span in ctx.
+func HashPasswordContext(ctx context.Context, password string) (string, error) {
+	_, span := otel.Tracer(tracerName).Start(ctx, "auth.HashPassword")
+	defer span.End()
     if len(password) < 1 {
         return "",errors.New("password cannot be empty.")
     }
@@ -22,6 +33,14 @@ func HashPassword(password string) (string, error) {
 }
 
 func CheckPasswordHash(password, hash string) (bool,error) {
+	return CheckPasswordHashContext(context.Background(), password, hash)
+}
+
+// CheckPasswordHashContext is CheckPasswordHash, traced as a child of the
+// span in ctx.
+func CheckPasswordHashContext(ctx context.Context, password, hash string) (bool, error) {
+	_, span := otel.Tracer(tracerName).Start(ctx, "auth.CheckPasswordHash")
+	defer span.End()
     if len(password) < 1 {
         return false,errors.New("password cannot be empty")
     }
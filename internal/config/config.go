@@ -0,0 +1,320 @@
+// Package config loads the server configuration from defaults, an optional
+// YAML or TOML file, the environment and command line flags, in increasing
+// order of precedence.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secrets when the configuration is printed.
+const Redacted = "[REDACTED]"
+
+// ConfigFileEnv names the environment variable holding the path of the
+// configuration file, which the -config flag overrides.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Every field carries an env tag naming its environment variable. Its flag
+// is the same name in lower case with dashes, so DB_URL is set by -db-url.
+// Fields tagged secret are redacted when printed; secret:"url" only hides
+// the password of a URL.
+type Config struct {
+	Platform    string `yaml:"platform" toml:"platform" env:"PLATFORM" usage:"\"dev\" enables development only endpoints"`
+	LogLevel    string `yaml:"log_level" toml:"log_level" env:"LOG_LEVEL" usage:"debug, info, warn or error"`
+	DBURL       string `yaml:"db_url" toml:"db_url" env:"DB_URL" secret:"url" usage:"postgres connection URL or key=value string, or sqlite:FILE"`
+	TokenSecret string `yaml:"token_secret" toml:"token_secret" env:"TOKEN_STRING" secret:"true" usage:"secret signing access tokens, at least 64 characters"`
+	PolkaAPIKey string `yaml:"polka_api_key" toml:"polka_api_key" env:"POLKA_KEY" secret:"true" usage:"API key Polka webhooks must present"`
+
+	Migrate bool `yaml:"migrate" toml:"migrate" env:"MIGRATE" usage:"apply pending database migrations before serving"`
+
+	ChirpUndoWindow      time.Duration `yaml:"chirp_undo_window" toml:"chirp_undo_window" env:"CHIRP_UNDO_WINDOW" usage:"how long a deleted chirp can be restored"`
+	AccountDeletionGrace time.Duration `yaml:"account_deletion_grace" toml:"account_deletion_grace" env:"ACCOUNT_DELETION_GRACE" usage:"how long a deleted account can be recovered"`
+	AccountStatusTTL     time.Duration `yaml:"account_status_ttl" toml:"account_status_ttl" env:"ACCOUNT_STATUS_TTL" usage:"how long suspensions and bans are cached before other instances' changes show up"`
+
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	Server   Server   `yaml:"server" toml:"server"`
+	Database Database `yaml:"database" toml:"database"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TRACE_EXPORTER" usage:"none, stdout (written to standard error), file or otlp"`
+	File     string `yaml:"file" toml:"file" env:"TRACE_FILE" usage:"file the file exporter writes spans to"`
+}
+
+type Server struct {
+	Addr              string        `yaml:"addr" toml:"addr" env:"ADDR" usage:"address to listen on"`
+	GRPCAddr          string        `yaml:"grpc_addr" toml:"grpc_addr" env:"GRPC_ADDR" usage:"address the gRPC server listens on, empty to turn it off"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"READ_HEADER_TIMEOUT" usage:"time allowed to read request headers"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"READ_TIMEOUT" usage:"time allowed to read a whole request"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"WRITE_TIMEOUT" usage:"time allowed to write a response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"IDLE_TIMEOUT" usage:"how long idle keep-alive connections are kept"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long in-flight requests get to finish on shutdown"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes" env:"MAX_HEADER_BYTES" usage:"maximum size of request headers"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" toml:"max_body_bytes" env:"MAX_BODY_BYTES" usage:"maximum size of request bodies"`
+
+	// TLSCertFile and TLSKeyFile serve TLS from a certificate on disk.
+	// Otherwise, if AutocertDomains is set, certificates for those domains
+	// are fetched from Let's Encrypt and cached in AutocertCacheDir.
+	TLSCertFile      string   `yaml:"tls_cert_file" toml:"tls_cert_file" env:"TLS_CERT_FILE" usage:"TLS certificate file"`
+	TLSKeyFile       string   `yaml:"tls_key_file" toml:"tls_key_file" env:"TLS_KEY_FILE" usage:"TLS key file"`
+	AutocertDomains  []string `yaml:"autocert_domains" toml:"autocert_domains" env:"AUTOCERT_DOMAINS" usage:"comma separated domains to get Let's Encrypt certificates for"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir" toml:"autocert_cache_dir" env:"AUTOCERT_CACHE_DIR" usage:"directory caching Let's Encrypt certificates"`
+	AutocertEmail    string   `yaml:"autocert_email" toml:"autocert_email" env:"AUTOCERT_EMAIL" usage:"contact email for Let's Encrypt"`
+}
+
+type Database struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections, 0 for no limit"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"maximum age of a connection, 0 for no limit"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"maximum idle time of a connection, 0 for no limit"`
+}
+
+// Default returns the configuration used for anything left unset.
+func Default() Config {
+	return Config{
+		LogLevel:             "info",
+		ChirpUndoWindow:      10 * time.Minute,
+		AccountDeletionGrace: 30 * 24 * time.Hour,
+		AccountStatusTTL:     5 * time.Second,
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		Server: Server{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+			MaxHeaderBytes:    64 << 10,
+			MaxBodyBytes:      1 << 20,
+			AutocertCacheDir:  "autocert-cache",
+		},
+		Database: Database{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+	}
+}
+
+// Load builds the configuration for a command invoked with args, reading
+// the environment through getenv. It returns a *ValidationError listing
+// every problem found, alongside the configuration, if it isn't valid.
+func Load(name string, args []string, getenv func(string) string) (Config, error) {
+	cfg, rest, err := load(name, args, getenv, Config.validate)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+	return cfg, err
+}
+
+// LoadDatabase is Load for commands that only use the database, so only the
+// database settings are validated. Parsing stops at the first argument that
+// isn't a flag, and the arguments from there on are returned for the
+// command to parse.
+func LoadDatabase(name string, args []string, getenv func(string) string) (Config, []string, error) {
+	return load(name, args, getenv, Config.validateDatabase)
+}
+
+func load(name string, args []string, getenv func(string) string, validate func(Config) []string) (Config, []string, error) {
+	cfg := Default()
+	fields := fieldsOf(&cfg)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", getenv(ConfigFileEnv), "YAML or TOML configuration file")
+	flagValues := map[string]string{}
+	for _, f := range fields {
+		setFlag := func(v string) error {
+			flagValues[f.env] = v
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.flag(), f.usage, setFlag)
+		} else {
+			fs.Func(f.flag(), f.usage, setFlag)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+	rest := fs.Args()
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return cfg, rest, err
+		}
+	}
+
+	var problems []string
+	for _, f := range fields {
+		v, ok := flagValues[f.env]
+		source := "flag -" + f.flag()
+		if !ok {
+			v = getenv(f.env)
+			source = f.env
+			ok = v != ""
+		}
+		if !ok {
+			continue
+		}
+		if err := f.set(v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", source, err))
+		}
+	}
+	problems = append(problems, validate(cfg)...)
+	slices.Sort(problems)
+	if len(problems) > 0 {
+		return cfg, rest, &ValidationError{Problems: problems}
+	}
+	return cfg, rest, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	case ".toml":
+		var md toml.MetaData
+		md, err = toml.Decode(string(data), cfg)
+		if err == nil && len(md.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown keys %v", md.Undecoded())
+		}
+	default:
+		return fmt.Errorf("config file %s: unknown format %q, want .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ValidationError lists everything wrong with a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Print writes every setting, with secrets redacted, as ENV_NAME=value
+// lines.
+func (c Config) Print(w io.Writer) {
+	for _, f := range fieldsOf(&c) {
+		fmt.Fprintf(w, "%s=%s\n", f.env, f.redacted())
+	}
+}
+
+type field struct {
+	env    string
+	usage  string
+	secret string
+	value  reflect.Value
+}
+
+func (f field) flag() string {
+	return strings.ReplaceAll(strings.ToLower(f.env), "_", "-")
+}
+
+// fieldsOf lists the settings of cfg, walking into nested sections.
+func fieldsOf(cfg *Config) []field {
+	var fields []field
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeFor[time.Duration]() {
+				walk(v.Field(i))
+				continue
+			}
+			fields = append(fields, field{
+				env:    sf.Tag.Get("env"),
+				usage:  sf.Tag.Get("usage"),
+				secret: sf.Tag.Get("secret"),
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+	return fields
+}
+
+func (f field) set(s string) error {
+	v := f.value
+	switch {
+	case v.Type() == reflect.TypeFor[time.Duration]():
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		panic(fmt.Sprintf("config: unsupported field type %s", v.Type()))
+	}
+	return nil
+}
+
+func (f field) redacted() string {
+	var s string
+	switch v := f.value.Interface().(type) {
+	case time.Duration:
+		s = v.String()
+	case []string:
+		s = strings.Join(v, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" {
+		return s
+	}
+	switch f.secret {
+	case "true":
+		return Redacted
+	case "url":
+		return redactURL(s)
+	}
+	return s
+}
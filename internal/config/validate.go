@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// MinTokenSecretLength is the shortest secret accepted for signing tokens.
+const MinTokenSecretLength = 64
+
+var traceExporters = []string{"none", "stdout", "file", "otlp"}
+
+// validate returns every problem with c.
+func (c Config) validate() []string {
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	problems = append(problems, c.validateDatabase()...)
+	if c.TokenSecret == "" {
+		problem("TOKEN_STRING is required")
+	} else if len(c.TokenSecret) < MinTokenSecretLength {
+		problem("TOKEN_STRING must be at least %d characters, got %d", MinTokenSecretLength, len(c.TokenSecret))
+	}
+	if c.PolkaAPIKey == "" {
+		problem("POLKA_KEY is required")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		problem("LOG_LEVEL: unknown level %q", c.LogLevel)
+	}
+	if c.ChirpUndoWindow < 0 {
+		problem("CHIRP_UNDO_WINDOW can't be negative")
+	}
+	if c.AccountDeletionGrace < 0 {
+		problem("ACCOUNT_DELETION_GRACE can't be negative")
+	}
+	if c.AccountStatusTTL < 0 {
+		problem("ACCOUNT_STATUS_TTL can't be negative")
+	}
+
+	if !slices.Contains(traceExporters, c.Tracing.Exporter) {
+		problem("TRACE_EXPORTER: unknown exporter %q, want one of %v", c.Tracing.Exporter, traceExporters)
+	}
+	if c.Tracing.Exporter == "file" && c.Tracing.File == "" {
+		problem("TRACE_FILE is required by the file exporter")
+	}
+
+	s := c.Server
+	if _, _, err := net.SplitHostPort(s.Addr); err != nil {
+		problem("ADDR: %v", err)
+	}
+	if s.GRPCAddr != "" {
+		if _, _, err := net.SplitHostPort(s.GRPCAddr); err != nil {
+			problem("GRPC_ADDR: %v", err)
+		} else if s.GRPCAddr == s.Addr {
+			problem("GRPC_ADDR must differ from ADDR")
+		}
+	}
+	for name, d := range map[string]int64{
+		"READ_HEADER_TIMEOUT": int64(s.ReadHeaderTimeout),
+		"READ_TIMEOUT":        int64(s.ReadTimeout),
+		"WRITE_TIMEOUT":       int64(s.WriteTimeout),
+		"IDLE_TIMEOUT":        int64(s.IdleTimeout),
+		"SHUTDOWN_TIMEOUT":    int64(s.ShutdownTimeout),
+		"MAX_HEADER_BYTES":    int64(s.MaxHeaderBytes),
+		"MAX_BODY_BYTES":      s.MaxBodyBytes,
+	} {
+		if d <= 0 {
+			problem("%s must be positive", name)
+		}
+	}
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		problem("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if s.TLSCertFile != "" && len(s.AutocertDomains) > 0 {
+		problem("TLS_CERT_FILE and AUTOCERT_DOMAINS can't both be set")
+	}
+	if len(s.AutocertDomains) > 0 && s.AutocertCacheDir == "" {
+		problem("AUTOCERT_CACHE_DIR is required with AUTOCERT_DOMAINS")
+	}
+
+	return problems
+}
+
+// validateDatabase returns every problem with the database settings.
+func (c Config) validateDatabase() []string {
+	var problems []string
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.DBURL == "" {
+		problem("DB_URL is required")
+	} else if err := checkDBURL(c.DBURL); err != nil {
+		problem("DB_URL: %v", err)
+	}
+	d := c.Database
+	if d.MaxOpenConns < 0 {
+		problem("DB_MAX_OPEN_CONNS can't be negative")
+	}
+	if d.MaxIdleConns < 0 {
+		problem("DB_MAX_IDLE_CONNS can't be negative")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		problem("DB_MAX_IDLE_CONNS (%d) can't exceed DB_MAX_OPEN_CONNS (%d)", d.MaxIdleConns, d.MaxOpenConns)
+	}
+	if d.ConnMaxLifetime < 0 {
+		problem("DB_CONN_MAX_LIFETIME can't be negative")
+	}
+	if d.ConnMaxIdleTime < 0 {
+		problem("DB_CONN_MAX_IDLE_TIME can't be negative")
+	}
+	return problems
+}
+
+func checkDBURL(s string) error {
+	if IsKeywordDSN(s) {
+		_, err := pq.NewConnector(s)
+		return err
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("not a URL")
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+	case "sqlite":
+		// sqlite:chirpy.db is relative to the working directory, and
+		// sqlite:///var/lib/chirpy/chirpy.db absolute.
+		if u.Host != "" {
+			return fmt.Errorf("a sqlite URL names a file, as sqlite:chirpy.db or sqlite:///var/lib/chirpy/chirpy.db")
+		}
+		if u.Opaque == "" && u.Path == "" {
+			return fmt.Errorf("missing database file")
+		}
+		return nil
+	default:
+		return fmt.Errorf("scheme must be postgres, postgresql or sqlite, got %q", u.Scheme)
+	}
+	// A Unix socket is named by the host parameter, as in
+	// postgres:///chirpy?host=/var/run/postgresql.
+	if u.Host == "" && u.Query().Get("host") == "" {
+		return fmt.Errorf("missing host")
+	}
+	if u.Path == "" || u.Path == "/" {
+		return fmt.Errorf("missing database name")
+	}
+	return nil
+}
+
+// IsKeywordDSN reports whether s is a libpq keyword/value connection string,
+// as "host=localhost dbname=chirpy", rather than a URL.
+func IsKeywordDSN(s string) bool {
+	u, err := url.Parse(s)
+	return (err != nil || u.Scheme == "") && strings.Contains(s, "=")
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactURL hides the password of a URL or connection string, or the whole
+// value if it can't be parsed.
+func redactURL(s string) string {
+	if IsKeywordDSN(s) {
+		return dsnPassword.ReplaceAllString(s, "${1}xxxxx")
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return Redacted
+	}
+	return u.Redacted()
+}
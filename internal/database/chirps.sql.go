@@ -7,8 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const addChirp = `-- name: AddChirp :one
@@ -18,7 +21,7 @@ INSERT INTO chirps(id,created_at,updated_at,body,user_id) VALUES(
     NOW(),
     $1,
     $2
-) RETURNING id, created_at, updated_at, body, user_id
+) RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
 `
 
 type AddChirpParams struct {
@@ -35,12 +38,95 @@ func (q *Queries) AddChirp(ctx context.Context, arg AddChirpParams) (Chirp, erro
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const addUnpublishedChirp = `-- name: AddUnpublishedChirp :one
+INSERT INTO chirps(id,created_at,updated_at,body,user_id,status,publish_at) VALUES(
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+) RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
+`
+
+type AddUnpublishedChirpParams struct {
+	ChirpBody string
+	UserID    uuid.UUID
+	Status    string
+	PublishAt sql.NullTime
+}
+
+func (q *Queries) AddUnpublishedChirp(ctx context.Context, arg AddUnpublishedChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, addUnpublishedChirp, arg.ChirpBody, arg.UserID, arg.Status, arg.PublishAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const adminDeleteChirp = `-- name: AdminDeleteChirp :one
+UPDATE chirps SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
+`
+
+func (q *Queries) AdminDeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, adminDeleteChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const adminRestoreChirp = `-- name: AdminRestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
+`
+
+func (q *Queries) AdminRestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, adminRestoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteChirpByID = `-- name: DeleteChirpByID :exec
-DELETE FROM chirps WHERE id = $1
+UPDATE chirps SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) DeleteChirpByID(ctx context.Context, chirpid uuid.UUID) error {
@@ -48,12 +134,76 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, chirpid uuid.UUID) error
 	return err
 }
 
+const deleteUnpublishedChirp = `-- name: DeleteUnpublishedChirp :execrows
+DELETE FROM chirps WHERE id = $1 AND user_id = $2 AND status <> 'published'
+`
+
+type DeleteUnpublishedChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteUnpublishedChirp(ctx context.Context, arg DeleteUnpublishedChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnpublishedChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at ASC
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $1::uuid
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $1::uuid AND user_mutes.muted_id = chirps.user_id
+)
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirpsForExport = `-- name: GetAllChirpsForExport :many
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps WHERE deleted_at IS NULL ORDER BY created_at, id
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetAllChirpsForExport(ctx context.Context) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsForExport)
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +217,45 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirpsForUser = `-- name: GetAllChirpsForUser :many
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps WHERE user_id = $1 ORDER BY created_at ASC
+`
+
+func (q *Queries) GetAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -82,11 +271,23 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE id = $1
+AND deleted_at IS NULL
+AND (status = 'published' OR user_id = $2::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2::uuid
+)
 `
 
-func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpByID, id)
+type GetChirpByIDParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpByID(ctx context.Context, arg GetChirpByIDParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByID, arg.ID, arg.ViewerID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -94,16 +295,141 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getChirpsAfter = `-- name: GetChirpsAfter :many
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE (created_at, id) > (SELECT c.created_at, c.id FROM chirps c WHERE c.id = $1)
+AND status = 'published'
+AND deleted_at IS NULL
+AND (cardinality($2::uuid[]) = 0 OR user_id = ANY($2::uuid[]))
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $3::uuid
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $3::uuid AND user_mutes.muted_id = chirps.user_id
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type GetChirpsAfterParams struct {
+	LastID    uuid.UUID
+	AuthorIds []uuid.UUID
+	ViewerID  uuid.NullUUID
+	MaxChirps int32
+}
+
+func (q *Queries) GetChirpsAfter(ctx context.Context, arg GetChirpsAfterParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsAfter, arg.LastID, pq.Array(arg.AuthorIds), arg.ViewerID, arg.MaxChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE id = ANY($1::uuid[])
+AND deleted_at IS NULL
+AND (status = 'published' OR user_id = $2::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2::uuid
+)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsFromUser = `-- name: GetChirpsFromUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE user_id = $1
+AND status = 'published'
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2::uuid
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $2::uuid AND user_mutes.muted_id = chirps.user_id
+)
+ORDER BY created_at ASC
 `
 
-func (q *Queries) GetChirpsFromUser(ctx context.Context, authorID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsFromUser, authorID)
+type GetChirpsFromUserParams struct {
+	AuthorID uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsFromUser(ctx context.Context, arg GetChirpsFromUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsFromUser, arg.AuthorID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +443,9 @@ func (q *Queries) GetChirpsFromUser(ctx context.Context, authorID uuid.UUID) ([]
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -130,3 +459,274 @@ func (q *Queries) GetChirpsFromUser(ctx context.Context, authorID uuid.UUID) ([]
 	}
 	return items, nil
 }
+
+const getRecentChirpsByAuthors = `-- name: GetRecentChirpsByAuthors :many
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE user_id = ANY($1::uuid[])
+AND status = 'published'
+AND deleted_at IS NULL
+AND (
+    SELECT COUNT(*) FROM chirps newer
+    WHERE newer.user_id = chirps.user_id
+    AND newer.status = 'published'
+    AND newer.deleted_at IS NULL
+    AND (newer.created_at, newer.id) > (chirps.created_at, chirps.id)
+) < $2::int
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $3::uuid
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE user_mutes.muter_id = $3::uuid AND user_mutes.muted_id = chirps.user_id
+)
+ORDER BY user_id, created_at DESC, id DESC
+`
+
+type GetRecentChirpsByAuthorsParams struct {
+	AuthorIds    []uuid.UUID
+	MaxPerAuthor int32
+	ViewerID     uuid.NullUUID
+}
+
+func (q *Queries) GetRecentChirpsByAuthors(ctx context.Context, arg GetRecentChirpsByAuthorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentChirpsByAuthors, pq.Array(arg.AuthorIds), arg.MaxPerAuthor, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnpublishedChirpsForUser = `-- name: GetUnpublishedChirpsForUser :many
+SELECT id, created_at, updated_at, body, user_id, status, publish_at, deleted_at FROM chirps
+WHERE user_id = $1 AND status <> 'published' AND deleted_at IS NULL
+ORDER BY publish_at ASC NULLS LAST, created_at ASC
+`
+
+func (q *Queries) GetUnpublishedChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getUnpublishedChirpsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importChirp = `-- name: ImportChirp :execrows
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, status, publish_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT DO NOTHING
+`
+
+type ImportChirpParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Body      string
+	UserID    uuid.UUID
+	Status    string
+	PublishAt sql.NullTime
+}
+
+func (q *Queries) ImportChirp(ctx context.Context, arg ImportChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, importChirp, arg.ID, arg.CreatedAt, arg.UpdatedAt, arg.Body, arg.UserID, arg.Status, arg.PublishAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const publishChirp = `-- name: PublishChirp :one
+UPDATE chirps
+SET status = 'published', publish_at = NULL, created_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status <> 'published' AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
+`
+
+type PublishChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) PublishChirp(ctx context.Context, arg PublishChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, publishChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET status = 'published', publish_at = NULL, created_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT due.id FROM chirps due
+    WHERE due.status = 'scheduled' AND due.publish_at <= NOW() AND due.deleted_at IS NULL
+    ORDER BY due.publish_at ASC
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+AND status = 'scheduled'
+RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context, batchSize int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE chirps.deleted_at < $1
+AND NOT EXISTS (
+    SELECT 1 FROM users WHERE users.id = chirps.user_id AND users.deleted_at IS NOT NULL
+)
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at >= $3
+RETURNING id, created_at, updated_at, body, user_id, status, publish_at, deleted_at
+`
+
+type RestoreChirpParams struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	DeletedAfter sql.NullTime
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.DeletedAfter)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreChirpsForUser = `-- name: RestoreChirpsForUser :exec
+UPDATE chirps SET deleted_at = NULL, updated_at = NOW()
+WHERE user_id = $1 AND deleted_at = $2
+`
+
+type RestoreChirpsForUserParams struct {
+	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) RestoreChirpsForUser(ctx context.Context, arg RestoreChirpsForUserParams) error {
+	_, err := q.db.ExecContext(ctx, restoreChirpsForUser, arg.UserID, arg.DeletedAt)
+	return err
+}
+
+const softDeleteChirpsForUser = `-- name: SoftDeleteChirpsForUser :exec
+UPDATE chirps SET deleted_at = $1, updated_at = NOW()
+WHERE user_id = $2 AND deleted_at IS NULL
+`
+
+type SoftDeleteChirpsForUserParams struct {
+	DeletedAt sql.NullTime
+	UserID    uuid.UUID
+}
+
+func (q *Queries) SoftDeleteChirpsForUser(ctx context.Context, arg SoftDeleteChirpsForUserParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirpsForUser, arg.DeletedAt, arg.UserID)
+	return err
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// QueryObserver is told how long each query run through an instrumented
+// DBTX took. query is the sqlc query name, or "unknown" for SQL that wasn't
+// generated by sqlc.
+type QueryObserver func(query string, d time.Duration)
+
+// Instrument wraps db so that every query run through it is reported to
+// observe.
+func Instrument(db DBTX, observe QueryObserver) DBTX {
+	return &instrumentedDB{db: db, observe: observe}
+}
+
+type instrumentedDB struct {
+	db      DBTX
+	observe QueryObserver
+}
+
+func (i *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer i.track(query, time.Now())
+	return i.db.ExecContext(ctx, query, args...)
+}
+
+func (i *instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return i.db.PrepareContext(ctx, query)
+}
+
+func (i *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer i.track(query, time.Now())
+	return i.db.QueryContext(ctx, query, args...)
+}
+
+func (i *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer i.track(query, time.Now())
+	return i.db.QueryRowContext(ctx, query, args...)
+}
+
+func (i *instrumentedDB) track(query string, start time.Time) {
+	i.observe(queryName(query), time.Since(start))
+}
+
+// queryName reads the name out of the "-- name: GetChirpByID :one" header
+// sqlc puts at the top of every generated query.
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	name, _, ok := strings.Cut(rest, " ")
+	if !ok {
+		return "unknown"
+	}
+	return name
+}
@@ -67,8 +67,50 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (GetRefresh
 	return i, err
 }
 
+const getRefreshTokensForUser = `-- name: GetRefreshTokensForUser :many
+SELECT created_at,updated_at,expires_at,revoked_at FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at ASC
+`
+
+type GetRefreshTokensForUserRow struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+}
+
+func (q *Queries) GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]GetRefreshTokensForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRefreshTokensForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRefreshTokensForUserRow
+	for rows.Next() {
+		var i GetRefreshTokensForUserRow
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByRefreshToken = `-- name: GetUserByRefreshToken :one
 SELECT users.id,
+users.status,
 refresh_tokens.token,
 refresh_tokens.expires_at,
 refresh_tokens.revoked_at
@@ -80,6 +122,7 @@ WHERE refresh_tokens.token = $1
 
 type GetUserByRefreshTokenRow struct {
 	ID        uuid.UUID
+	Status    string
 	Token     sql.NullString
 	ExpiresAt sql.NullTime
 	RevokedAt sql.NullTime
@@ -90,6 +133,7 @@ func (q *Queries) GetUserByRefreshToken(ctx context.Context, token string) (GetU
 	var i GetUserByRefreshTokenRow
 	err := row.Scan(
 		&i.ID,
+		&i.Status,
 		&i.Token,
 		&i.ExpiresAt,
 		&i.RevokedAt,
@@ -97,6 +141,16 @@ func (q *Queries) GetUserByRefreshToken(ctx context.Context, token string) (GetU
 	return i, err
 }
 
+const revokeAllRefreshTokensForUser = `-- name: RevokeAllRefreshTokensForUser :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAllRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeAllRefreshTokensForUser, userID)
+	return err
+}
+
 const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1
 `
@@ -0,0 +1,146 @@
+// Package sqlite runs Chirpy's queries on SQLite, for development and tests
+// without a database server. The queries in sql/sqlite/queries are the
+// Postgres ones in SQLite's dialect, and sqlc generates Queries from them
+// here; Store adapts those to database.Store.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	sqlitedriver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// timeFormat is how timestamps are stored: as UTC text, fixed width so they
+// sort by time, to the microsecond like Postgres.
+const timeFormat = "2006-01-02 15:04:05.000000"
+
+func init() {
+	// The queries keep the Postgres functions they need, so that they read
+	// the same as the Postgres ones.
+	sqlitedriver.MustRegisterScalarFunction("now", 0, func(*sqlitedriver.FunctionContext, []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format(timeFormat), nil
+	})
+	sqlitedriver.MustRegisterScalarFunction("gen_random_uuid", 0, func(*sqlitedriver.FunctionContext, []driver.Value) (driver.Value, error) {
+		return uuid.NewString(), nil
+	})
+}
+
+// Open opens the database file at path, creating it if need be. Foreign keys
+// are enforced as they are in Postgres, and writers wait for each other
+// rather than failing.
+func Open(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, errors.New("sqlite: no database file")
+	}
+	params := url.Values{
+		"_pragma": {"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"},
+		// A transaction takes the write lock when it begins, rather than
+		// failing when it first writes if another has it.
+		"_txlock": {"immediate"},
+	}
+	return sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+}
+
+// IsForeignKeyViolation reports whether err is SQLite refusing a row that
+// references one that doesn't exist.
+func IsForeignKeyViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+// IsUniqueViolation reports whether err is SQLite refusing a row that
+// duplicates another's unique key.
+func IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+// utcDB stores the times passed to queries in timeFormat, in UTC, so they
+// compare with each other and with now() as text.
+type utcDB struct {
+	db DBTX
+}
+
+func (u utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return u.db.ExecContext(ctx, query, formatTimes(args)...)
+}
+
+func (u utcDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return u.db.PrepareContext(ctx, query)
+}
+
+func (u utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return u.db.QueryContext(ctx, query, formatTimes(args)...)
+}
+
+func (u utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return u.db.QueryRowContext(ctx, query, formatTimes(args)...)
+}
+
+func formatTimes(args []interface{}) []interface{} {
+	args = slices.Clone(args)
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC().Format(timeFormat)
+		case sql.NullTime:
+			if v.Valid {
+				args[i] = v.Time.UTC().Format(timeFormat)
+			} else {
+				args[i] = nil
+			}
+		}
+	}
+	return args
+}
+
+// asTime reads a timestamp SQLite computed, which the driver leaves as text
+// since it has no column type to go by.
+func asTime(v interface{}) time.Time {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}
+	case time.Time:
+		return v
+	case string:
+		for _, layout := range []string{timeFormat, time.DateTime, time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	panic(fmt.Sprintf("sqlite: %v (%T) is not a timestamp", v, v))
+}
+
+// asBool reads a boolean SQLite computed, which it returns as an integer.
+func asBool(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	}
+	panic(fmt.Sprintf("sqlite: %v (%T) is not a boolean", v, v))
+}
+
+func convertAll[From, To any](rows []From, convert func(From) To) []To {
+	if rows == nil {
+		return nil
+	}
+	converted := make([]To, len(rows))
+	for i, row := range rows {
+		converted[i] = convert(row)
+	}
+	return converted
+}
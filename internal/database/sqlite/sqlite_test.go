@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/sql/sqlite/schema"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := goose.NewProvider(goose.DialectSQLite3, db, schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// TestQueriesPrepare checks every generated query against the migrated
+// schema, including the ones no other test runs.
+func TestQueriesPrepare(t *testing.T) {
+	db := newTestDB(t)
+	files, _ := filepath.Glob("*.sql.go")
+	fset := token.NewFileSet()
+	var n int
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(node ast.Node) bool {
+			lit, ok := node.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			query, _ := strconv.Unquote(lit.Value)
+			if !strings.HasPrefix(query, "-- name: ") {
+				return true
+			}
+			n++
+			stmt, err := db.Prepare(query)
+			if err != nil {
+				t.Errorf("%s: %v", queryName(query), err)
+				return true
+			}
+			stmt.Close()
+			return true
+		})
+	}
+	if n < len(files) {
+		t.Fatalf("found %d queries in %d files", n, len(files))
+	}
+}
+
+func queryName(query string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(query, "-- name: "), " ")
+	return name
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(newTestDB(t))
+	start := time.Now().UTC().Truncate(time.Microsecond)
+
+	walt, err := s.CreateUser(ctx, database.CreateUserParams{Email: "Walt@BreakingBad.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walt.CreatedAt.Before(start) || walt.CreatedAt.Location() != time.UTC {
+		t.Errorf("created at %v, want UTC after %v", walt.CreatedAt, start)
+	}
+	jesse, err := s.CreateUser(ctx, database.CreateUserParams{Email: "jesse@breakingbad.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Timestamps compare as text, so a parameter mustn't be cast to a number.
+	for _, tt := range []struct {
+		params database.AdminSearchUsersParams
+		want   int
+	}{
+		{database.AdminSearchUsersParams{CreatedAfter: sql.NullTime{Time: start.Add(-time.Hour), Valid: true}}, 2},
+		{database.AdminSearchUsersParams{CreatedAfter: sql.NullTime{Time: start.Add(time.Hour), Valid: true}}, 0},
+		{database.AdminSearchUsersParams{CreatedBefore: sql.NullTime{Time: start.Add(time.Hour), Valid: true}}, 2},
+	} {
+		tt.params.MaxUsers = 10
+		if users, err := s.AdminSearchUsers(ctx, tt.params); err != nil || len(users) != tt.want {
+			t.Errorf("AdminSearchUsers(%+v) = %d users (%v), want %d", tt.params, len(users), err, tt.want)
+		}
+	}
+
+	token, err := s.AddRefreshToken(ctx, database.AddRefreshTokenParams{Token: "t", UserID: walt.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := token.ExpiresAt.Sub(token.CreatedAt); d < 60*24*time.Hour-time.Second || d > 60*24*time.Hour {
+		t.Errorf("refresh token lasts %v, want 60 days", d)
+	}
+
+	// Times passed in compare with the ones now() stored.
+	deleted, err := s.MarkUserDeleted(ctx, jesse.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.PurgeDeletedUsers(ctx, sql.NullTime{Time: deleted.Time.Add(-time.Second), Valid: true}); err != nil || n != 0 {
+		t.Errorf("purged %d users deleted before they were (%v)", n, err)
+	}
+	if err := s.CancelUserDeletion(ctx, jesse.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.BlockUser(ctx, database.BlockUserParams{BlockerID: walt.ID, BlockedID: jesse.ID}); err != nil {
+		t.Fatal(err)
+	}
+	blocked, err := s.IsBlockedByAnyEmail(ctx, database.IsBlockedByAnyEmailParams{
+		UserID: jesse.ID,
+		Emails: []string{"hank@dea.gov", "walt@breakingbad.com"},
+	})
+	if err != nil || !blocked {
+		t.Errorf("IsBlockedByAnyEmail = %v, %v; want blocked, whatever the email's case", blocked, err)
+	}
+	allowed, err := s.CanSendDirectMessage(ctx, database.CanSendDirectMessageParams{SenderID: jesse.ID, RecipientID: walt.ID})
+	if err != nil || allowed {
+		t.Errorf("CanSendDirectMessage = %v, %v; want blocked", allowed, err)
+	}
+	err = s.BlockUser(ctx, database.BlockUserParams{BlockerID: walt.ID, BlockedID: uuid.New()})
+	if !IsForeignKeyViolation(err) {
+		t.Errorf("blocking a user that doesn't exist: err = %v, want a foreign key violation", err)
+	}
+
+	for _, author := range []uuid.UUID{walt.ID, walt.ID, jesse.ID} {
+		if _, err := s.AddChirp(ctx, database.AddChirpParams{ChirpBody: "hello", ID: author}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recent, err := s.GetRecentChirpsByAuthors(ctx, database.GetRecentChirpsByAuthorsParams{
+		AuthorIds:    []uuid.UUID{walt.ID, jesse.ID},
+		MaxPerAuthor: 1,
+	})
+	if err != nil || len(recent) != 2 {
+		t.Errorf("GetRecentChirpsByAuthors = %d chirps (%v), want one each", len(recent), err)
+	}
+	perDay, err := s.ChirpsPerDay(ctx, start.Add(-time.Hour))
+	if err != nil || len(perDay) == 0 || perDay[len(perDay)-1].Count == 0 {
+		t.Fatalf("ChirpsPerDay = %v, %v", perDay, err)
+	}
+	if day := perDay[len(perDay)-1].Day; !day.Equal(time.Now().UTC().Truncate(24 * time.Hour)) {
+		t.Errorf("ChirpsPerDay counted %v, want today", day)
+	}
+}
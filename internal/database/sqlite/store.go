@@ -0,0 +1,473 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Store runs the queries on SQLite, taking and returning the types of the
+// Postgres ones so that the server can use either as a database.Store.
+type Store struct {
+	q *Queries
+}
+
+var _ database.Store = (*Store)(nil)
+
+// NewStore returns a Store that runs its queries through db, a database
+// opened with Open or a transaction on one.
+func NewStore(db DBTX) *Store {
+	return &Store{q: New(utcDB{db})}
+}
+
+func (s *Store) AdminGetUser(ctx context.Context, id uuid.UUID) (database.AdminGetUserRow, error) {
+	row, err := s.q.AdminGetUser(ctx, id)
+	return database.AdminGetUserRow(row), err
+}
+
+func (s *Store) AdminSearchUsers(ctx context.Context, arg database.AdminSearchUsersParams) ([]database.AdminSearchUsersRow, error) {
+	rows, err := s.q.AdminSearchUsers(ctx, AdminSearchUsersParams{
+		Query:         arg.Query,
+		EmailDomain:   arg.EmailDomain,
+		IsChirpyRed:   arg.IsChirpyRed,
+		CreatedAfter:  arg.CreatedAfter,
+		CreatedBefore: arg.CreatedBefore,
+		Skip:          int64(arg.Skip),
+		MaxUsers:      int64(arg.MaxUsers),
+	})
+	return convertAll(rows, func(row AdminSearchUsersRow) database.AdminSearchUsersRow { return database.AdminSearchUsersRow(row) }), err
+}
+
+func (s *Store) ChirpsPerDay(ctx context.Context, since time.Time) ([]database.ChirpsPerDayRow, error) {
+	rows, err := s.q.ChirpsPerDay(ctx, since)
+	return convertAll(rows, func(row ChirpsPerDayRow) database.ChirpsPerDayRow {
+		return database.ChirpsPerDayRow{
+			Day:   asTime(row.Day),
+			Count: row.Count,
+		}
+	}), err
+}
+
+func (s *Store) CountActiveUsers(ctx context.Context, since time.Time) (int64, error) {
+	return s.q.CountActiveUsers(ctx, since)
+}
+
+func (s *Store) CountUsers(ctx context.Context) (database.CountUsersRow, error) {
+	row, err := s.q.CountUsers(ctx)
+	return database.CountUsersRow(row), err
+}
+
+func (s *Store) IsAdmin(ctx context.Context, id uuid.UUID) (bool, error) {
+	return s.q.IsAdmin(ctx, id)
+}
+
+func (s *Store) ListRestrictedUsers(ctx context.Context) ([]database.ListRestrictedUsersRow, error) {
+	rows, err := s.q.ListRestrictedUsers(ctx)
+	return convertAll(rows, func(row ListRestrictedUsersRow) database.ListRestrictedUsersRow {
+		return database.ListRestrictedUsersRow(row)
+	}), err
+}
+
+func (s *Store) SetUserAdmin(ctx context.Context, arg database.SetUserAdminParams) error {
+	return s.q.SetUserAdmin(ctx, SetUserAdminParams(arg))
+}
+
+func (s *Store) SetUserStatus(ctx context.Context, arg database.SetUserStatusParams) (int64, error) {
+	return s.q.SetUserStatus(ctx, SetUserStatusParams(arg))
+}
+
+func (s *Store) SignupsPerDay(ctx context.Context, since time.Time) ([]database.SignupsPerDayRow, error) {
+	rows, err := s.q.SignupsPerDay(ctx, since)
+	return convertAll(rows, func(row SignupsPerDayRow) database.SignupsPerDayRow {
+		return database.SignupsPerDayRow{
+			Day:   asTime(row.Day),
+			Count: row.Count,
+		}
+	}), err
+}
+
+func (s *Store) BlockUser(ctx context.Context, arg database.BlockUserParams) error {
+	return s.q.BlockUser(ctx, BlockUserParams(arg))
+}
+
+func (s *Store) GetHiddenAuthors(ctx context.Context, viewerID uuid.UUID) ([]uuid.UUID, error) {
+	return s.q.GetHiddenAuthors(ctx, viewerID)
+}
+
+func (s *Store) IsBlockedByAnyEmail(ctx context.Context, arg database.IsBlockedByAnyEmailParams) (bool, error) {
+	return s.q.IsBlockedByAnyEmail(ctx, IsBlockedByAnyEmailParams(arg))
+}
+
+func (s *Store) MuteUser(ctx context.Context, arg database.MuteUserParams) error {
+	return s.q.MuteUser(ctx, MuteUserParams(arg))
+}
+
+func (s *Store) UnblockUser(ctx context.Context, arg database.UnblockUserParams) error {
+	return s.q.UnblockUser(ctx, UnblockUserParams(arg))
+}
+
+func (s *Store) UnmuteUser(ctx context.Context, arg database.UnmuteUserParams) error {
+	return s.q.UnmuteUser(ctx, UnmuteUserParams(arg))
+}
+
+func (s *Store) AddChirp(ctx context.Context, arg database.AddChirpParams) (database.Chirp, error) {
+	row, err := s.q.AddChirp(ctx, AddChirpParams(arg))
+	return database.Chirp(row), err
+}
+
+func (s *Store) AddUnpublishedChirp(ctx context.Context, arg database.AddUnpublishedChirpParams) (database.Chirp, error) {
+	row, err := s.q.AddUnpublishedChirp(ctx, AddUnpublishedChirpParams(arg))
+	return database.Chirp(row), err
+}
+
+func (s *Store) AdminDeleteChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	row, err := s.q.AdminDeleteChirp(ctx, id)
+	return database.Chirp(row), err
+}
+
+func (s *Store) AdminRestoreChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	row, err := s.q.AdminRestoreChirp(ctx, id)
+	return database.Chirp(row), err
+}
+
+func (s *Store) DeleteChirpByID(ctx context.Context, chirpid uuid.UUID) error {
+	return s.q.DeleteChirpByID(ctx, chirpid)
+}
+
+func (s *Store) DeleteUnpublishedChirp(ctx context.Context, arg database.DeleteUnpublishedChirpParams) (int64, error) {
+	return s.q.DeleteUnpublishedChirp(ctx, DeleteUnpublishedChirpParams(arg))
+}
+
+func (s *Store) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	rows, err := s.q.GetAllChirps(ctx, viewerID)
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetAllChirpsForExport(ctx context.Context) ([]database.Chirp, error) {
+	rows, err := s.q.GetAllChirpsForExport(ctx)
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	rows, err := s.q.GetAllChirpsForUser(ctx, userID)
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetChirpByID(ctx context.Context, arg database.GetChirpByIDParams) (database.Chirp, error) {
+	row, err := s.q.GetChirpByID(ctx, GetChirpByIDParams(arg))
+	return database.Chirp(row), err
+}
+
+// GetChirpsAfter passes the authors as a comma separated list, which the
+// query searches for each chirp's author: a UUID can't match across the
+// commas, so that's exact.
+func (s *Store) GetChirpsAfter(ctx context.Context, arg database.GetChirpsAfterParams) ([]database.Chirp, error) {
+	authorIDs := make([]string, len(arg.AuthorIds))
+	for i, id := range arg.AuthorIds {
+		authorIDs[i] = id.String()
+	}
+	rows, err := s.q.GetChirpsAfter(ctx, GetChirpsAfterParams{
+		LastID:    arg.LastID,
+		AuthorIds: strings.Join(authorIDs, ","),
+		ViewerID:  arg.ViewerID,
+		MaxChirps: int64(arg.MaxChirps),
+	})
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetChirpsByIDs(ctx context.Context, arg database.GetChirpsByIDsParams) ([]database.Chirp, error) {
+	rows, err := s.q.GetChirpsByIDs(ctx, GetChirpsByIDsParams{
+		ViewerID: arg.ViewerID,
+		Ids:      arg.Ids,
+	})
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetChirpsFromUser(ctx context.Context, arg database.GetChirpsFromUserParams) ([]database.Chirp, error) {
+	rows, err := s.q.GetChirpsFromUser(ctx, GetChirpsFromUserParams(arg))
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetRecentChirpsByAuthors(ctx context.Context, arg database.GetRecentChirpsByAuthorsParams) ([]database.Chirp, error) {
+	rows, err := s.q.GetRecentChirpsByAuthors(ctx, GetRecentChirpsByAuthorsParams{
+		MaxPerAuthor: int64(arg.MaxPerAuthor),
+		ViewerID:     arg.ViewerID,
+		AuthorIds:    arg.AuthorIds,
+	})
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) GetUnpublishedChirpsForUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	rows, err := s.q.GetUnpublishedChirpsForUser(ctx, userID)
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) ImportChirp(ctx context.Context, arg database.ImportChirpParams) (int64, error) {
+	return s.q.ImportChirp(ctx, ImportChirpParams(arg))
+}
+
+func (s *Store) PublishChirp(ctx context.Context, arg database.PublishChirpParams) (database.Chirp, error) {
+	row, err := s.q.PublishChirp(ctx, PublishChirpParams(arg))
+	return database.Chirp(row), err
+}
+
+func (s *Store) PublishDueChirps(ctx context.Context, batchSize int32) ([]database.Chirp, error) {
+	rows, err := s.q.PublishDueChirps(ctx, int64(batchSize))
+	return convertAll(rows, func(row Chirp) database.Chirp { return database.Chirp(row) }), err
+}
+
+func (s *Store) PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	return s.q.PurgeDeletedChirps(ctx, deletedBefore)
+}
+
+func (s *Store) RestoreChirp(ctx context.Context, arg database.RestoreChirpParams) (database.Chirp, error) {
+	row, err := s.q.RestoreChirp(ctx, RestoreChirpParams(arg))
+	return database.Chirp(row), err
+}
+
+func (s *Store) RestoreChirpsForUser(ctx context.Context, arg database.RestoreChirpsForUserParams) error {
+	return s.q.RestoreChirpsForUser(ctx, RestoreChirpsForUserParams(arg))
+}
+
+func (s *Store) SoftDeleteChirpsForUser(ctx context.Context, arg database.SoftDeleteChirpsForUserParams) error {
+	return s.q.SoftDeleteChirpsForUser(ctx, SoftDeleteChirpsForUserParams(arg))
+}
+
+func (s *Store) AddConversationMember(ctx context.Context, arg database.AddConversationMemberParams) error {
+	return s.q.AddConversationMember(ctx, AddConversationMemberParams(arg))
+}
+
+func (s *Store) AddDirectConversation(ctx context.Context, arg database.AddDirectConversationParams) (int64, error) {
+	return s.q.AddDirectConversation(ctx, AddDirectConversationParams(arg))
+}
+
+func (s *Store) AddMessage(ctx context.Context, arg database.AddMessageParams) (database.Message, error) {
+	row, err := s.q.AddMessage(ctx, AddMessageParams(arg))
+	return database.Message(row), err
+}
+
+func (s *Store) AddToDMAllowlist(ctx context.Context, arg database.AddToDMAllowlistParams) error {
+	return s.q.AddToDMAllowlist(ctx, AddToDMAllowlistParams(arg))
+}
+
+func (s *Store) CanSendDirectMessage(ctx context.Context, arg database.CanSendDirectMessageParams) (bool, error) {
+	allowed, err := s.q.CanSendDirectMessage(ctx, CanSendDirectMessageParams(arg))
+	return asBool(allowed), err
+}
+
+func (s *Store) CreateConversation(ctx context.Context, createdBy uuid.UUID) (database.Conversation, error) {
+	row, err := s.q.CreateConversation(ctx, createdBy)
+	return database.Conversation(row), err
+}
+
+func (s *Store) GetConversationMembers(ctx context.Context, conversationID uuid.UUID) ([]database.ConversationMember, error) {
+	rows, err := s.q.GetConversationMembers(ctx, conversationID)
+	return convertAll(rows, func(row ConversationMember) database.ConversationMember { return database.ConversationMember(row) }), err
+}
+
+func (s *Store) GetConversationsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetConversationsForUserRow, error) {
+	rows, err := s.q.GetConversationsForUser(ctx, userID)
+	return convertAll(rows, func(row GetConversationsForUserRow) database.GetConversationsForUserRow {
+		return database.GetConversationsForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetDMAllowlist(ctx context.Context, userID uuid.UUID) ([]database.DmAllowlist, error) {
+	rows, err := s.q.GetDMAllowlist(ctx, userID)
+	return convertAll(rows, func(row DmAllowlist) database.DmAllowlist { return database.DmAllowlist(row) }), err
+}
+
+func (s *Store) GetDMAllowlistOnly(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return s.q.GetDMAllowlistOnly(ctx, userID)
+}
+
+func (s *Store) GetDirectConversation(ctx context.Context, arg database.GetDirectConversationParams) (database.Conversation, error) {
+	row, err := s.q.GetDirectConversation(ctx, GetDirectConversationParams(arg))
+	return database.Conversation(row), err
+}
+
+func (s *Store) GetMessages(ctx context.Context, arg database.GetMessagesParams) ([]database.Message, error) {
+	rows, err := s.q.GetMessages(ctx, GetMessagesParams{
+		ConversationID: arg.ConversationID,
+		PageOffset:     int64(arg.PageOffset),
+		PageSize:       int64(arg.PageSize),
+	})
+	return convertAll(rows, func(row Message) database.Message { return database.Message(row) }), err
+}
+
+func (s *Store) GetMessagesSentByUser(ctx context.Context, senderID uuid.UUID) ([]database.Message, error) {
+	rows, err := s.q.GetMessagesSentByUser(ctx, senderID)
+	return convertAll(rows, func(row Message) database.Message { return database.Message(row) }), err
+}
+
+func (s *Store) IsConversationMember(ctx context.Context, arg database.IsConversationMemberParams) (bool, error) {
+	return s.q.IsConversationMember(ctx, IsConversationMemberParams(arg))
+}
+
+func (s *Store) MarkConversationRead(ctx context.Context, arg database.MarkConversationReadParams) error {
+	return s.q.MarkConversationRead(ctx, MarkConversationReadParams(arg))
+}
+
+func (s *Store) RemoveFromDMAllowlist(ctx context.Context, arg database.RemoveFromDMAllowlistParams) error {
+	return s.q.RemoveFromDMAllowlist(ctx, RemoveFromDMAllowlistParams(arg))
+}
+
+func (s *Store) SetDMAllowlistOnly(ctx context.Context, arg database.SetDMAllowlistOnlyParams) error {
+	return s.q.SetDMAllowlistOnly(ctx, SetDMAllowlistOnlyParams(arg))
+}
+
+func (s *Store) TouchConversation(ctx context.Context, id uuid.UUID) error {
+	return s.q.TouchConversation(ctx, id)
+}
+
+func (s *Store) AddNotification(ctx context.Context, arg database.AddNotificationParams) error {
+	return s.q.AddNotification(ctx, AddNotificationParams(arg))
+}
+
+func (s *Store) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.CountUnreadNotifications(ctx, userID)
+}
+
+func (s *Store) GetNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]database.NotificationPreference, error) {
+	rows, err := s.q.GetNotificationPreferences(ctx, userID)
+	return convertAll(rows, func(row NotificationPreference) database.NotificationPreference {
+		return database.NotificationPreference(row)
+	}), err
+}
+
+func (s *Store) GetNotificationsForUser(ctx context.Context, arg database.GetNotificationsForUserParams) ([]database.Notification, error) {
+	rows, err := s.q.GetNotificationsForUser(ctx, GetNotificationsForUserParams{
+		UserID:     arg.UserID,
+		PageOffset: int64(arg.PageOffset),
+		PageSize:   int64(arg.PageSize),
+	})
+	return convertAll(rows, func(row Notification) database.Notification { return database.Notification(row) }), err
+}
+
+func (s *Store) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	return s.q.MarkAllNotificationsRead(ctx, userID)
+}
+
+func (s *Store) MarkNotificationsRead(ctx context.Context, arg database.MarkNotificationsReadParams) error {
+	return s.q.MarkNotificationsRead(ctx, MarkNotificationsReadParams(arg))
+}
+
+func (s *Store) SetNotificationPreference(ctx context.Context, arg database.SetNotificationPreferenceParams) error {
+	return s.q.SetNotificationPreference(ctx, SetNotificationPreferenceParams(arg))
+}
+
+func (s *Store) AddRefreshToken(ctx context.Context, arg database.AddRefreshTokenParams) (database.RefreshToken, error) {
+	row, err := s.q.AddRefreshToken(ctx, AddRefreshTokenParams(arg))
+	return database.RefreshToken(row), err
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, token string) (database.GetRefreshTokenRow, error) {
+	row, err := s.q.GetRefreshToken(ctx, token)
+	return database.GetRefreshTokenRow(row), err
+}
+
+func (s *Store) GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]database.GetRefreshTokensForUserRow, error) {
+	rows, err := s.q.GetRefreshTokensForUser(ctx, userID)
+	return convertAll(rows, func(row GetRefreshTokensForUserRow) database.GetRefreshTokensForUserRow {
+		return database.GetRefreshTokensForUserRow(row)
+	}), err
+}
+
+func (s *Store) GetUserByRefreshToken(ctx context.Context, token string) (database.GetUserByRefreshTokenRow, error) {
+	row, err := s.q.GetUserByRefreshToken(ctx, token)
+	return database.GetUserByRefreshTokenRow(row), err
+}
+
+func (s *Store) RevokeAllRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	return s.q.RevokeAllRefreshTokensForUser(ctx, userID)
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, token string) error {
+	return s.q.RevokeRefreshToken(ctx, token)
+}
+
+func (s *Store) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	return s.q.CancelUserDeletion(ctx, id)
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.CreateUserRow, error) {
+	row, err := s.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.CreateUserRow(row), err
+}
+
+func (s *Store) DowngradeUserFromChirpyRed(ctx context.Context, userID uuid.UUID) error {
+	return s.q.DowngradeUserFromChirpyRed(ctx, userID)
+}
+
+func (s *Store) GetAllUsers(ctx context.Context) ([]database.User, error) {
+	rows, err := s.q.GetAllUsers(ctx)
+	return convertAll(rows, func(row User) database.User { return database.User(row) }), err
+}
+
+func (s *Store) GetHashedPasswordByID(ctx context.Context, id uuid.UUID) (string, error) {
+	return s.q.GetHashedPasswordByID(ctx, id)
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.GetUserByEmailRow, error) {
+	row, err := s.q.GetUserByEmail(ctx, email)
+	return database.GetUserByEmailRow(row), err
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.GetUserByIDRow, error) {
+	row, err := s.q.GetUserByID(ctx, id)
+	return database.GetUserByIDRow(row), err
+}
+
+func (s *Store) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]database.GetUsersByIDsRow, error) {
+	rows, err := s.q.GetUsersByIDs(ctx, ids)
+	return convertAll(rows, func(row GetUsersByIDsRow) database.GetUsersByIDsRow { return database.GetUsersByIDsRow(row) }), err
+}
+
+func (s *Store) ImportUser(ctx context.Context, arg database.ImportUserParams) (int64, error) {
+	return s.q.ImportUser(ctx, ImportUserParams(arg))
+}
+
+func (s *Store) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.ListUsersRow, error) {
+	rows, err := s.q.ListUsers(ctx, ListUsersParams{
+		Skip:     int64(arg.Skip),
+		MaxUsers: int64(arg.MaxUsers),
+	})
+	return convertAll(rows, func(row ListUsersRow) database.ListUsersRow { return database.ListUsersRow(row) }), err
+}
+
+func (s *Store) MarkUserDeleted(ctx context.Context, id uuid.UUID) (sql.NullTime, error) {
+	return s.q.MarkUserDeleted(ctx, id)
+}
+
+func (s *Store) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	return s.q.PurgeDeletedUsers(ctx, deletedBefore)
+}
+
+func (s *Store) ResetUserTable(ctx context.Context) error {
+	return s.q.ResetUserTable(ctx)
+}
+
+func (s *Store) SearchUsers(ctx context.Context, arg database.SearchUsersParams) ([]database.SearchUsersRow, error) {
+	rows, err := s.q.SearchUsers(ctx, SearchUsersParams{
+		Query:    arg.Query,
+		MaxUsers: int64(arg.MaxUsers),
+	})
+	return convertAll(rows, func(row SearchUsersRow) database.SearchUsersRow { return database.SearchUsersRow(row) }), err
+}
+
+func (s *Store) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	return s.q.SetUserPassword(ctx, SetUserPasswordParams(arg))
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.UpdateUserRow, error) {
+	row, err := s.q.UpdateUser(ctx, UpdateUserParams(arg))
+	return database.UpdateUserRow(row), err
+}
+
+func (s *Store) UpgradeUserToChirpyRed(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return s.q.UpgradeUserToChirpyRed(ctx, userID)
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store is every query, as *Queries runs them on Postgres. The SQLite
+// backend in the sqlite package is one too; code that should run on either
+// takes a Store rather than *Queries.
+type Store interface {
+	AdminGetUser(ctx context.Context, id uuid.UUID) (AdminGetUserRow, error)
+	AdminSearchUsers(ctx context.Context, arg AdminSearchUsersParams) ([]AdminSearchUsersRow, error)
+	ChirpsPerDay(ctx context.Context, since time.Time) ([]ChirpsPerDayRow, error)
+	CountActiveUsers(ctx context.Context, since time.Time) (int64, error)
+	CountUsers(ctx context.Context) (CountUsersRow, error)
+	IsAdmin(ctx context.Context, id uuid.UUID) (bool, error)
+	ListRestrictedUsers(ctx context.Context) ([]ListRestrictedUsersRow, error)
+	SetUserAdmin(ctx context.Context, arg SetUserAdminParams) error
+	SetUserStatus(ctx context.Context, arg SetUserStatusParams) (int64, error)
+	SignupsPerDay(ctx context.Context, since time.Time) ([]SignupsPerDayRow, error)
+
+	BlockUser(ctx context.Context, arg BlockUserParams) error
+	GetHiddenAuthors(ctx context.Context, viewerID uuid.UUID) ([]uuid.UUID, error)
+	IsBlockedByAnyEmail(ctx context.Context, arg IsBlockedByAnyEmailParams) (bool, error)
+	MuteUser(ctx context.Context, arg MuteUserParams) error
+	UnblockUser(ctx context.Context, arg UnblockUserParams) error
+	UnmuteUser(ctx context.Context, arg UnmuteUserParams) error
+
+	AddChirp(ctx context.Context, arg AddChirpParams) (Chirp, error)
+	AddUnpublishedChirp(ctx context.Context, arg AddUnpublishedChirpParams) (Chirp, error)
+	AdminDeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
+	AdminRestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
+	DeleteChirpByID(ctx context.Context, chirpid uuid.UUID) error
+	DeleteUnpublishedChirp(ctx context.Context, arg DeleteUnpublishedChirpParams) (int64, error)
+	GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error)
+	GetAllChirpsForExport(ctx context.Context) ([]Chirp, error)
+	GetAllChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error)
+	GetChirpByID(ctx context.Context, arg GetChirpByIDParams) (Chirp, error)
+	GetChirpsAfter(ctx context.Context, arg GetChirpsAfterParams) ([]Chirp, error)
+	GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error)
+	GetChirpsFromUser(ctx context.Context, arg GetChirpsFromUserParams) ([]Chirp, error)
+	GetRecentChirpsByAuthors(ctx context.Context, arg GetRecentChirpsByAuthorsParams) ([]Chirp, error)
+	GetUnpublishedChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error)
+	ImportChirp(ctx context.Context, arg ImportChirpParams) (int64, error)
+	PublishChirp(ctx context.Context, arg PublishChirpParams) (Chirp, error)
+	PublishDueChirps(ctx context.Context, batchSize int32) ([]Chirp, error)
+	PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error)
+	RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error)
+	RestoreChirpsForUser(ctx context.Context, arg RestoreChirpsForUserParams) error
+	SoftDeleteChirpsForUser(ctx context.Context, arg SoftDeleteChirpsForUserParams) error
+
+	AddConversationMember(ctx context.Context, arg AddConversationMemberParams) error
+	AddDirectConversation(ctx context.Context, arg AddDirectConversationParams) (int64, error)
+	AddMessage(ctx context.Context, arg AddMessageParams) (Message, error)
+	AddToDMAllowlist(ctx context.Context, arg AddToDMAllowlistParams) error
+	CanSendDirectMessage(ctx context.Context, arg CanSendDirectMessageParams) (bool, error)
+	CreateConversation(ctx context.Context, createdBy uuid.UUID) (Conversation, error)
+	GetConversationMembers(ctx context.Context, conversationID uuid.UUID) ([]ConversationMember, error)
+	GetConversationsForUser(ctx context.Context, userID uuid.UUID) ([]GetConversationsForUserRow, error)
+	GetDMAllowlist(ctx context.Context, userID uuid.UUID) ([]DmAllowlist, error)
+	GetDMAllowlistOnly(ctx context.Context, userID uuid.UUID) (bool, error)
+	GetDirectConversation(ctx context.Context, arg GetDirectConversationParams) (Conversation, error)
+	GetMessages(ctx context.Context, arg GetMessagesParams) ([]Message, error)
+	GetMessagesSentByUser(ctx context.Context, senderID uuid.UUID) ([]Message, error)
+	IsConversationMember(ctx context.Context, arg IsConversationMemberParams) (bool, error)
+	MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) error
+	RemoveFromDMAllowlist(ctx context.Context, arg RemoveFromDMAllowlistParams) error
+	SetDMAllowlistOnly(ctx context.Context, arg SetDMAllowlistOnlyParams) error
+	TouchConversation(ctx context.Context, id uuid.UUID) error
+
+	AddNotification(ctx context.Context, arg AddNotificationParams) error
+	CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error)
+	GetNotificationsForUser(ctx context.Context, arg GetNotificationsForUserParams) ([]Notification, error)
+	MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error
+	MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) error
+	SetNotificationPreference(ctx context.Context, arg SetNotificationPreferenceParams) error
+
+	AddRefreshToken(ctx context.Context, arg AddRefreshTokenParams) (RefreshToken, error)
+	GetRefreshToken(ctx context.Context, token string) (GetRefreshTokenRow, error)
+	GetRefreshTokensForUser(ctx context.Context, userID uuid.UUID) ([]GetRefreshTokensForUserRow, error)
+	GetUserByRefreshToken(ctx context.Context, token string) (GetUserByRefreshTokenRow, error)
+	RevokeAllRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error
+	RevokeRefreshToken(ctx context.Context, token string) error
+
+	CancelUserDeletion(ctx context.Context, id uuid.UUID) error
+	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
+	DowngradeUserFromChirpyRed(ctx context.Context, userID uuid.UUID) error
+	GetAllUsers(ctx context.Context) ([]User, error)
+	GetHashedPasswordByID(ctx context.Context, id uuid.UUID) (string, error)
+	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error)
+	GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]GetUsersByIDsRow, error)
+	ImportUser(ctx context.Context, arg ImportUserParams) (int64, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	MarkUserDeleted(ctx context.Context, id uuid.UUID) (sql.NullTime, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error)
+	ResetUserTable(ctx context.Context) error
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error)
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error)
+	UpgradeUserToChirpyRed(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+var _ Store = (*Queries)(nil)
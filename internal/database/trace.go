@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/Blustak/bootdev-chirpy/internal/database"
+
+// Trace wraps db so that every query run through it gets a span, a child of
+// the span in the query's context, named after the sqlc query. system is the
+// semconv db.system of the database, such as semconv.DBSystemPostgreSQL.
+//
+// A span covers running the query, not reading its results: for :many
+// queries it ends when QueryContext returns, before the caller iterates and
+// closes the rows.
+func Trace(db DBTX, system attribute.KeyValue) DBTX {
+	return &tracedDB{db: db, system: system}
+}
+
+type tracedDB struct {
+	db     DBTX
+	system attribute.KeyValue
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+	res, err := t.db.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return res, err
+}
+
+func (t *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.db.PrepareContext(ctx, query)
+}
+
+// QueryContext ends its span as soon as the query has run; *sql.Rows is a
+// concrete type, so there is no hook for ending it when the rows are closed.
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.startSpan(ctx, query)
+	defer span.End()
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+func (t *tracedDB) startSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			t.system,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -7,11 +7,22 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const cancelUserDeletion = `-- name: CancelUserDeletion :exec
+UPDATE users SET deleted_at = NULL, updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, cancelUserDeletion, id)
+	return err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users(id, created_at, updated_at, email,hashed_password) VALUES(
     gen_random_uuid(),
@@ -48,6 +59,57 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 	return i, err
 }
 
+const downgradeUserFromChirpyRed = `-- name: DowngradeUserFromChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = FALSE
+WHERE id = $1
+`
+
+func (q *Queries) DowngradeUserFromChirpyRed(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, downgradeUserFromChirpyRed, userID)
+	return err
+}
+
+const getAllUsers = `-- name: GetAllUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, dm_allowlist_only, deleted_at, is_admin, status, suspended_until, status_reason FROM users ORDER BY created_at, id
+`
+
+func (q *Queries) GetAllUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getAllUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.DmAllowlistOnly,
+			&i.DeletedAt,
+			&i.IsAdmin,
+			&i.Status,
+			&i.SuspendedUntil,
+			&i.StatusReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getHashedPasswordByID = `-- name: GetHashedPasswordByID :one
 SELECT hashed_password FROM users WHERE id = $1
 `
@@ -60,15 +122,19 @@ func (q *Queries) GetHashedPasswordByID(ctx context.Context, id uuid.UUID) (stri
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id,created_at,updated_at,email,is_chirpy_red FROM users WHERE email = $1
+SELECT id,created_at,updated_at,email,is_chirpy_red,deleted_at,status,suspended_until,status_reason FROM users WHERE email = $1
 `
 
 type GetUserByEmailRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Email       string
-	IsChirpyRed bool
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Email          string
+	IsChirpyRed    bool
+	DeletedAt      sql.NullTime
+	Status         string
+	SuspendedUntil sql.NullTime
+	StatusReason   string
 }
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
@@ -80,10 +146,185 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEm
 		&i.UpdatedAt,
 		&i.Email,
 		&i.IsChirpyRed,
+		&i.DeletedAt,
+		&i.Status,
+		&i.SuspendedUntil,
+		&i.StatusReason,
 	)
 	return i, err
 }
 
+const getUserByID = `-- name: GetUserByID :one
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users WHERE id = $1
+`
+
+type GetUserByIDRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	DmAllowlistOnly bool
+	DeletedAt       sql.NullTime
+}
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i GetUserByIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.IsChirpyRed,
+		&i.DmAllowlistOnly,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getUsersByIDs = `-- name: GetUsersByIDs :many
+SELECT id,created_at,updated_at,email,is_chirpy_red FROM users
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+`
+
+type GetUsersByIDsRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Email       string
+	IsChirpyRed bool
+}
+
+func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]GetUsersByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByIDsRow
+	for rows.Next() {
+		var i GetUsersByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.IsChirpyRed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importUser = `-- name: ImportUser :execrows
+INSERT INTO users(id, created_at, updated_at, email, hashed_password, is_chirpy_red)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT DO NOTHING
+`
+
+type ImportUserParams struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Email          string
+	HashedPassword string
+	IsChirpyRed    bool
+}
+
+func (q *Queries) ImportUser(ctx context.Context, arg ImportUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, importUser, arg.ID, arg.CreatedAt, arg.UpdatedAt, arg.Email, arg.HashedPassword, arg.IsChirpyRed)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users
+ORDER BY created_at, id
+LIMIT $1 OFFSET $2
+`
+
+type ListUsersParams struct {
+	MaxUsers int32
+	Skip     int32
+}
+
+type ListUsersRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	DmAllowlistOnly bool
+	DeletedAt       sql.NullTime
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.MaxUsers, arg.Skip)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.IsChirpyRed,
+			&i.DmAllowlistOnly,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markUserDeleted = `-- name: MarkUserDeleted :one
+UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING deleted_at
+`
+
+func (q *Queries) MarkUserDeleted(ctx context.Context, id uuid.UUID) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, markUserDeleted, id)
+	var deleted_at sql.NullTime
+	err := row.Scan(&deleted_at)
+	return deleted_at, err
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const resetUserTable = `-- name: ResetUserTable :exec
 DELETE FROM users
 `
@@ -93,6 +334,73 @@ func (q *Queries) ResetUserTable(ctx context.Context) error {
 	return err
 }
 
+const searchUsers = `-- name: SearchUsers :many
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users
+WHERE strpos(lower(email), lower($1::text)) > 0
+ORDER BY email
+LIMIT $2
+`
+
+type SearchUsersParams struct {
+	Query    string
+	MaxUsers int32
+}
+
+type SearchUsersRow struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           string
+	IsChirpyRed     bool
+	DmAllowlistOnly bool
+	DeletedAt       sql.NullTime
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsers, arg.Query, arg.MaxUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.IsChirpyRed,
+			&i.DmAllowlistOnly,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users SET hashed_password = $1, updated_at = NOW() WHERE id = $2
+`
+
+type SetUserPasswordParams struct {
+	HashedPassword string
+	ID             uuid.UUID
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.HashedPassword, arg.ID)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET email = $1,
@@ -129,13 +437,16 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateU
 	return i, err
 }
 
-const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :exec
+const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :execrows
 UPDATE USERS
 SET is_chirpy_red = TRUE
 WHERE id = $1
 `
 
-func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, userID uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, userID)
-	return err
+func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
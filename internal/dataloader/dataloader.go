@@ -0,0 +1,170 @@
+// Package dataloader batches lookups by key. Loads made close together, as
+// when a GraphQL query resolves the same field for every item of a list,
+// become one call to a batch function, and each key is only fetched once.
+//
+// A Loader caches everything it loads, so it should live for one request.
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values for keys. Keys it leaves out of the map load
+// the zero value.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+const (
+	defaultWait     = time.Millisecond
+	defaultMaxBatch = 100
+)
+
+// Loader loads values with a BatchFunc, batching and caching them.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+	full    chan struct{}
+}
+
+// Option configures a Loader.
+type Option func(*options)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// Wait sets how long a batch waits for more keys after its first, 1ms by
+// default.
+func Wait(d time.Duration) Option {
+	return func(o *options) { o.wait = d }
+}
+
+// MaxBatch sets the most keys fetched at once, 100 by default.
+func MaxBatch(n int) Option {
+	return func(o *options) { o.maxBatch = n }
+}
+
+// New returns a Loader fetching values with fetch.
+func New[K comparable, V any](fetch BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	o := options{wait: defaultWait, maxBatch: defaultMaxBatch}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     o.wait,
+		maxBatch: o.maxBatch,
+		cache:    make(map[K]*result[V]),
+	}
+}
+
+// Load returns the value for key, waiting for the batch it joins to be
+// fetched. The batch is fetched with the context of the Load that started
+// it.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.cache[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.cache[key] = r
+		l.add(ctx, key, r)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadAll loads the values for keys, in the same order.
+func (l *Loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// add adds key to the current batch, starting one if there isn't one. The
+// caller holds l.mu.
+func (l *Loader[K, V]) add(ctx context.Context, key K, r *result[V]) {
+	if l.batch == nil {
+		l.batch = &batch[K, V]{full: make(chan struct{})}
+		go l.run(context.WithoutCancel(ctx), l.batch)
+	}
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, r)
+	if len(b.keys) >= l.maxBatch {
+		l.batch = nil
+		close(b.full)
+	}
+}
+
+// run fetches b once it's full or has waited long enough.
+func (l *Loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case <-b.full:
+	case <-timer.C:
+		l.mu.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.mu.Unlock()
+	}
+
+	values, err := l.fetch(ctx, b.keys)
+	for i, key := range b.keys {
+		r := b.results[i]
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[key]
+		}
+		close(r.done)
+	}
+	if err != nil {
+		// Let a later Load try again.
+		l.mu.Lock()
+		for _, key := range b.keys {
+			delete(l.cache, key)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,94 @@
+package dataloader_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/dataloader"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]int
+	err     error
+}
+
+func (r *recorder) fetch(ctx context.Context, keys []int) (map[int]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, slices.Sorted(slices.Values(keys)))
+	if r.err != nil {
+		return nil, r.err
+	}
+	values := make(map[int]string)
+	for _, k := range keys {
+		if k >= 0 {
+			values[k] = string(rune('a' + k))
+		}
+	}
+	return values, nil
+}
+
+func TestBatchesAndCaches(t *testing.T) {
+	r := &recorder{}
+	l := dataloader.New(r.fetch, dataloader.Wait(5*time.Millisecond))
+	ctx := context.Background()
+
+	values, err := l.LoadAll(ctx, []int{0, 1, 2, 1, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c", "b", ""}; !slices.Equal(values, want) {
+		t.Errorf("LoadAll = %q, want %q", values, want)
+	}
+	if v, err := l.Load(ctx, 2); err != nil || v != "c" {
+		t.Errorf("Load(2) = %q, %v", v, err)
+	}
+	if len(r.batches) != 1 || !slices.Equal(r.batches[0], []int{-1, 0, 1, 2}) {
+		t.Errorf("fetched %v, want one batch of each key", r.batches)
+	}
+}
+
+func TestMaxBatch(t *testing.T) {
+	r := &recorder{}
+	l := dataloader.New(r.fetch, dataloader.Wait(time.Hour), dataloader.MaxBatch(2))
+	if _, err := l.LoadAll(context.Background(), []int{0, 1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.batches) != 2 {
+		t.Errorf("fetched %v, want two batches of two", r.batches)
+	}
+}
+
+func TestErrorsAreNotCached(t *testing.T) {
+	r := &recorder{err: errors.New("db is down")}
+	l := dataloader.New(r.fetch)
+	ctx := context.Background()
+	if _, err := l.Load(ctx, 1); err == nil {
+		t.Fatal("Load succeeded with a failing fetch")
+	}
+	r.mu.Lock()
+	r.err = nil
+	r.mu.Unlock()
+	if v, err := l.Load(ctx, 1); err != nil || v != "b" {
+		t.Errorf("Load after the error = %q, %v", v, err)
+	}
+}
+
+func TestLoadHonoursContext(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	l := dataloader.New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		<-block
+		return nil, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Load(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context's error", err)
+	}
+}
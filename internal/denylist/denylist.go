@@ -0,0 +1,175 @@
+// Package denylist caches which users are suspended, banned or deleted.
+// Access tokens are stateless, so every authenticated request is checked
+// against it; the cache keeps that from costing a query each time while
+// still picking up changes within seconds.
+package denylist
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Status is a user's account status.
+type Status string
+
+const (
+	Active    Status = "active"
+	Suspended Status = "suspended"
+	Banned    Status = "banned"
+	// Deleted is an account scheduled for deletion. Admins can't set it,
+	// but its access tokens are refused like a banned user's until the
+	// deletion is cancelled.
+	Deleted Status = "deleted"
+)
+
+func ValidStatus(s Status) bool {
+	return s == Active || s == Suspended || s == Banned
+}
+
+// Restriction is a suspension or ban. It's an error so handlers can return
+// it as the reason a request was refused.
+type Restriction struct {
+	Status Status
+	// Until is when a suspension ends; zero means it doesn't.
+	Until  time.Time
+	Reason string
+}
+
+func (r *Restriction) Error() string {
+	msg := "account is " + string(r.Status)
+	if !r.Until.IsZero() {
+		msg += " until " + r.Until.Format(time.RFC3339)
+	}
+	if r.Reason != "" {
+		msg += ": " + r.Reason
+	}
+	return msg
+}
+
+// ErrorCode is the API error code for the restriction.
+func (r *Restriction) ErrorCode() string {
+	return "account_" + string(r.Status)
+}
+
+// ReadOnly reports whether the user can still make requests that don't
+// change anything.
+func (r *Restriction) ReadOnly() bool {
+	return r.Status == Suspended
+}
+
+// inForce reports whether the restriction still applies at now.
+func (r *Restriction) inForce(now time.Time) bool {
+	return r.Status != Suspended || r.Until.IsZero() || now.Before(r.Until)
+}
+
+type Store interface {
+	ListRestrictedUsers(ctx context.Context) ([]database.ListRestrictedUsersRow, error)
+}
+
+// Denylist holds every restricted user, reloaded from the store once it's
+// older than its TTL.
+type Denylist struct {
+	store    Store
+	ttl      time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	entries  map[uuid.UUID]*Restriction
+	loadedAt time.Time
+	// loading is closed when the load in progress finishes; it's nil when
+	// there is none.
+	loading chan struct{}
+	// generation counts invalidations, so that a load started before one
+	// isn't kept.
+	generation int
+}
+
+func New(store Store, ttl time.Duration) *Denylist {
+	return &Denylist{store: store, ttl: ttl, now: time.Now}
+}
+
+// Check returns the restriction on userID, or nil if the user is in good
+// standing.
+func (d *Denylist) Check(ctx context.Context, userID uuid.UUID) (*Restriction, error) {
+	now := d.now()
+	entries, err := d.current(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := entries[userID]
+	if !ok || !r.inForce(now) {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// Invalidate makes the next Check reload the denylist, for changes made by
+// this instance to show up straight away.
+func (d *Denylist) Invalidate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = nil
+	d.generation++
+}
+
+// current returns the entries, reloading them if they've expired. The store
+// is queried without holding the lock, by one caller at a time: while it
+// reloads, the others keep using the expired entries, or wait if there are
+// none.
+func (d *Denylist) current(ctx context.Context, now time.Time) (map[uuid.UUID]*Restriction, error) {
+	for {
+		d.mu.Lock()
+		entries, loading := d.entries, d.loading
+		if entries != nil && (now.Sub(d.loadedAt) < d.ttl || loading != nil) {
+			d.mu.Unlock()
+			return entries, nil
+		}
+		if loading == nil {
+			break
+		}
+		d.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	d.loading = done
+	generation := d.generation
+	d.mu.Unlock()
+
+	entries, err := d.load(ctx)
+
+	d.mu.Lock()
+	d.loading = nil
+	if err == nil && generation == d.generation {
+		d.entries, d.loadedAt = entries, now
+	}
+	d.mu.Unlock()
+	close(done)
+	return entries, err
+}
+
+func (d *Denylist) load(ctx context.Context) (map[uuid.UUID]*Restriction, error) {
+	rows, err := d.store.ListRestrictedUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading denylist: %w", err)
+	}
+	entries := make(map[uuid.UUID]*Restriction, len(rows))
+	for _, row := range rows {
+		r := &Restriction{Status: Status(row.Status), Reason: row.StatusReason}
+		if row.SuspendedUntil.Valid {
+			r.Until = row.SuspendedUntil.Time
+		}
+		if row.DeletedAt.Valid {
+			r = &Restriction{Status: Deleted}
+		}
+		entries[row.ID] = r
+	}
+	return entries, nil
+}
@@ -0,0 +1,144 @@
+package denylist_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/google/uuid"
+)
+
+type fakeStore struct {
+	rows  []database.ListRestrictedUsersRow
+	loads int
+}
+
+func (s *fakeStore) ListRestrictedUsers(ctx context.Context) ([]database.ListRestrictedUsersRow, error) {
+	s.loads++
+	return s.rows, nil
+}
+
+func TestCheck(t *testing.T) {
+	banned, suspended, expired, deleted, active := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	store := &fakeStore{rows: []database.ListRestrictedUsersRow{
+		{ID: banned, Status: "banned", StatusReason: "spam"},
+		{ID: suspended, Status: "suspended", SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}},
+		{ID: expired, Status: "suspended", SuspendedUntil: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}},
+		{ID: deleted, Status: "active", DeletedAt: sql.NullTime{Time: time.Now(), Valid: true}},
+	}}
+	d := denylist.New(store, time.Hour)
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		want   denylist.Status
+	}{
+		{"banned", banned, denylist.Banned},
+		{"suspended", suspended, denylist.Suspended},
+		{"suspension over", expired, ""},
+		{"deleted", deleted, denylist.Deleted},
+		{"active", active, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := d.Check(context.Background(), tt.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if r != nil {
+					t.Errorf("got %v, want no restriction", r)
+				}
+				return
+			}
+			if r == nil || r.Status != tt.want {
+				t.Errorf("got %v, want %s", r, tt.want)
+			}
+		})
+	}
+	if store.loads != 1 {
+		t.Errorf("loaded %d times within the TTL, want 1", store.loads)
+	}
+}
+
+func TestInvalidateReloads(t *testing.T) {
+	userID := uuid.New()
+	store := &fakeStore{}
+	d := denylist.New(store, time.Hour)
+	if r, _ := d.Check(context.Background(), userID); r != nil {
+		t.Fatalf("got %v before the ban", r)
+	}
+
+	store.rows = []database.ListRestrictedUsersRow{{ID: userID, Status: "banned"}}
+	if r, _ := d.Check(context.Background(), userID); r != nil {
+		t.Fatalf("got %v, want the cached denylist to be used", r)
+	}
+	d.Invalidate()
+	r, err := d.Check(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r == nil || r.Status != denylist.Banned {
+		t.Errorf("got %v after Invalidate, want the ban", r)
+	}
+}
+
+// slowStore blocks loads after the first until release is closed.
+type slowStore struct {
+	fakeStore
+	loading chan struct{}
+	release chan struct{}
+}
+
+func (s *slowStore) ListRestrictedUsers(ctx context.Context) ([]database.ListRestrictedUsersRow, error) {
+	if s.loads > 0 {
+		s.loading <- struct{}{}
+		<-s.release
+	}
+	return s.fakeStore.ListRestrictedUsers(ctx)
+}
+
+func TestReloadDoesNotBlockChecks(t *testing.T) {
+	userID := uuid.New()
+	store := &slowStore{
+		fakeStore: fakeStore{rows: []database.ListRestrictedUsersRow{{ID: userID, Status: "banned"}}},
+		loading:   make(chan struct{}),
+		release:   make(chan struct{}),
+	}
+	// Every Check finds the entries expired.
+	d := denylist.New(store, 0)
+	if _, err := d.Check(context.Background(), userID); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan error)
+	go func() {
+		_, err := d.Check(context.Background(), userID)
+		reloaded <- err
+	}()
+	<-store.loading
+	for range 3 {
+		r, err := d.Check(context.Background(), userID)
+		if err != nil || r == nil || r.Status != denylist.Banned {
+			t.Fatalf("got %v, %v during a reload, want the ban from before it", r, err)
+		}
+	}
+	close(store.release)
+	if err := <-reloaded; err != nil {
+		t.Fatal(err)
+	}
+	if store.loads != 2 {
+		t.Errorf("loaded %d times, want 2", store.loads)
+	}
+}
+
+func TestRestrictionError(t *testing.T) {
+	until := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := &denylist.Restriction{Status: denylist.Suspended, Until: until, Reason: "spam"}
+	if got, want := r.Error(), "account is suspended until 2030-01-02T03:04:05Z: spam"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
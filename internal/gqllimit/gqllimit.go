@@ -0,0 +1,165 @@
+// Package gqllimit refuses GraphQL queries that would cost too much to run,
+// before they run. A query's depth is how deeply its fields nest; its
+// complexity estimates how many fields it resolves, counting the fields
+// under a list once per item the list can hold.
+package gqllimit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Error codes, as reported by ErrorCode.
+const (
+	CodeInvalidQuery    = "invalid_query"
+	CodeQueryTooDeep    = "query_too_deep"
+	CodeQueryTooComplex = "query_too_complex"
+)
+
+// Error is a query that can't be run.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns e.Code, for apierror.
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+// Limits are the most a query can cost. A zero limit isn't checked.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+	// DefaultListSize is how many items a list field without a limit
+	// argument is expected to hold. It defaults to 10.
+	DefaultListSize int
+}
+
+// Cost is what a query costs.
+type Cost struct {
+	Depth      int
+	Complexity int
+	// Mutation is set when the operation is a mutation.
+	Mutation bool
+}
+
+// Checker checks queries against a schema.
+type Checker struct {
+	schema *ast.Schema
+	limits Limits
+}
+
+// New returns a Checker for queries against the schema described by sdl.
+func New(sdl string, limits Limits) (*Checker, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("loading schema: %w", err)
+	}
+	if limits.DefaultListSize == 0 {
+		limits.DefaultListSize = 10
+	}
+	return &Checker{schema: schema, limits: limits}, nil
+}
+
+// Check returns what the operation named operationName costs, or an *Error
+// if the query is invalid or costs more than the limits.
+//
+// A list field's items are counted by its limit argument, when it has one.
+// Introspection is left out of the depth and counts its lists as one item:
+// the schema's validation already bounds how deeply it nests.
+func (c *Checker) Check(query, operationName string, vars map[string]any) (Cost, error) {
+	doc, errs := gqlparser.LoadQueryWithRules(c.schema, query, nil)
+	if len(errs) > 0 {
+		return Cost{}, &Error{Code: CodeInvalidQuery, Message: errs[0].Message}
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return Cost{}, &Error{Code: CodeInvalidQuery, Message: "no operation named " + operationName}
+	}
+	w := walker{limits: c.limits, vars: vars}
+	depth, complexity := w.selectionSet(op.SelectionSet, false)
+	cost := Cost{Depth: depth, Complexity: complexity, Mutation: op.Operation == ast.Mutation}
+	if c.limits.MaxDepth > 0 && cost.Depth > c.limits.MaxDepth {
+		return cost, &Error{Code: CodeQueryTooDeep, Message: fmt.Sprintf("query is nested %d deep, more than the limit of %d", cost.Depth, c.limits.MaxDepth)}
+	}
+	if c.limits.MaxComplexity > 0 && cost.Complexity > c.limits.MaxComplexity {
+		return cost, &Error{Code: CodeQueryTooComplex, Message: fmt.Sprintf("query has a complexity of %d, more than the limit of %d", cost.Complexity, c.limits.MaxComplexity)}
+	}
+	return cost, nil
+}
+
+type walker struct {
+	limits Limits
+	vars   map[string]any
+}
+
+// selectionSet returns the depth and complexity of set. Fragments are
+// expanded where they're spread; validation has ruled out cycles.
+func (w walker) selectionSet(set ast.SelectionSet, introspection bool) (depth, complexity int) {
+	for _, sel := range set {
+		var d, c int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d, c = w.field(sel, introspection)
+		case *ast.InlineFragment:
+			d, c = w.selectionSet(sel.SelectionSet, introspection)
+		case *ast.FragmentSpread:
+			d, c = w.selectionSet(sel.Definition.SelectionSet, introspection)
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (w walker) field(f *ast.Field, introspection bool) (depth, complexity int) {
+	introspection = introspection || strings.HasPrefix(f.Name, "__")
+	depth, complexity = w.selectionSet(f.SelectionSet, introspection)
+	if !introspection {
+		depth++
+	}
+	if f.Definition != nil && isList(f.Definition.Type) && !introspection {
+		complexity *= w.listSize(f)
+	}
+	return depth, complexity + 1
+}
+
+// listSize is how many items the list field f can hold.
+func (w walker) listSize(f *ast.Field) int {
+	if f.Definition.Arguments.ForName("limit") == nil {
+		return w.limits.DefaultListSize
+	}
+	n, err := toInt(f.ArgumentMap(w.vars)["limit"])
+	if err != nil || n < 1 {
+		// The resolver refuses it; count it as the default.
+		return w.limits.DefaultListSize
+	}
+	return n
+}
+
+func isList(t *ast.Type) bool {
+	return t != nil && t.Elem != nil
+}
+
+func toInt(v any) (int, error) {
+	switch v := v.(type) {
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	}
+	return 0, errors.New("not an integer")
+}
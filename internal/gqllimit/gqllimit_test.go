@@ -0,0 +1,103 @@
+package gqllimit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/gqllimit"
+)
+
+const schema = `
+type Query {
+	viewer: User
+	users: [User!]!
+}
+type Mutation {
+	rename(name: String!): User
+}
+type User {
+	name: String!
+	friends(limit: Int = 5): [User!]!
+}
+`
+
+func TestCheck(t *testing.T) {
+	checker, err := gqllimit.New(schema, gqllimit.Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  gqllimit.Cost
+	}{
+		{"scalar", `{ viewer { name } }`, nil, gqllimit.Cost{Depth: 2, Complexity: 2}},
+		{"default limit", `{ viewer { friends { name } } }`, nil, gqllimit.Cost{Depth: 3, Complexity: 1 + 1 + 5}},
+		{"limit argument", `{ viewer { friends(limit: 3) { name friends(limit: 2) { name } } } }`, nil,
+			gqllimit.Cost{Depth: 4, Complexity: 1 + 1 + 3*(1+1+2*1)}},
+		{"limit variable", `query($n: Int) { viewer { friends(limit: $n) { name } } }`, map[string]any{"n": float64(7)},
+			gqllimit.Cost{Depth: 3, Complexity: 1 + 1 + 7}},
+		{"list without limit", `{ users { name } }`, nil, gqllimit.Cost{Depth: 2, Complexity: 1 + 10}},
+		{"fragments", `{ viewer { ...F friends { ...F } } } fragment F on User { name }`, nil,
+			gqllimit.Cost{Depth: 3, Complexity: 1 + 1 + 1 + 5}},
+		{"introspection", `{ __schema { types { fields { name } } } viewer { __typename } }`, nil,
+			gqllimit.Cost{Depth: 1, Complexity: 4 + 2}},
+		{"mutation", `mutation { rename(name: "x") { name } }`, nil, gqllimit.Cost{Depth: 2, Complexity: 2, Mutation: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checker.Check(tt.query, "", tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Check = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	checker, err := gqllimit.New(schema, gqllimit.Limits{MaxDepth: 3, MaxComplexity: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{"within the limits", `{ viewer { friends(limit: 40) { name } } }`, ""},
+		{"too deep", `{ viewer { friends(limit: 1) { friends(limit: 1) { name } } } }`, gqllimit.CodeQueryTooDeep},
+		{"too complex", `{ viewer { friends(limit: 49) { name } } }`, gqllimit.CodeQueryTooComplex},
+		{"invalid", `{ viewer { age } }`, gqllimit.CodeInvalidQuery},
+		{"syntax error", `{ viewer `, gqllimit.CodeInvalidQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := checker.Check(tt.query, "", nil)
+			var e *gqllimit.Error
+			switch {
+			case tt.code == "" && err != nil:
+				t.Errorf("Check: %v", err)
+			case tt.code != "" && (!errors.As(err, &e) || e.Code != tt.code):
+				t.Errorf("Check: err = %v, want %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	checker, err := gqllimit.New(schema, gqllimit.Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := `query A { viewer { name } } mutation B { rename(name: "x") { name } }`
+	if cost, err := checker.Check(query, "B", nil); err != nil || !cost.Mutation {
+		t.Errorf("Check B = %+v, %v, want a mutation", cost, err)
+	}
+	if _, err := checker.Check(query, "", nil); err == nil {
+		t.Error("Check without an operation name succeeded with two operations")
+	}
+}
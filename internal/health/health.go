@@ -0,0 +1,128 @@
+// Package health runs the dependency checks behind the readiness endpoint.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	// failedCheckError is all Handler says about a failed check: the
+	// readiness endpoint is unauthenticated, and errors can describe the
+	// infrastructure behind it.
+	failedCheckError = "check failed"
+)
+
+// Check reports a problem with a dependency by returning an error.
+type Check func(ctx context.Context) error
+
+// Checker runs a set of named checks concurrently, each within a timeout.
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: map[string]Check{}}
+}
+
+// Add registers a check under name, replacing any check of the same name.
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency string `json:"latency"`
+}
+
+// Report is the outcome of every check. Status is StatusFail if any check
+// failed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Run runs every check and waits for them all to finish or time out.
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.names))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		check := c.checks[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := run(ctx, check)
+			res := Result{Status: StatusOK, Latency: time.Since(start).String()}
+			if err != nil {
+				res.Status = StatusFail
+				res.Error = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// run returns the result of check, or the context's error if the check
+// doesn't finish in time.
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Handler serves the report of every check as JSON, with status 503 if any
+// of them failed. Why a check failed is logged rather than served.
+func (c *Checker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		for name, res := range report.Checks {
+			if res.Status == StatusOK {
+				continue
+			}
+			slog.WarnContext(r.Context(), "readiness check failed", "check", name, "error", res.Error, "latency", res.Latency)
+			res.Error = failedCheckError
+			report.Checks[name] = res
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,78 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/health"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]health.Check
+		wantStatus int
+		wantFailed []string
+	}{
+		{
+			name: "all healthy",
+			checks: map[string]health.Check{
+				"database": func(context.Context) error { return nil },
+				"workers":  func(context.Context) error { return nil },
+			},
+			wantStatus: 200,
+		},
+		{
+			name: "failing dependency",
+			checks: map[string]health.Check{
+				"database": func(context.Context) error { return errors.New("connection refused") },
+				"workers":  func(context.Context) error { return nil },
+			},
+			wantStatus: 503,
+			wantFailed: []string{"database"},
+		},
+		{
+			name: "hanging dependency",
+			checks: map[string]health.Check{
+				"database": func(context.Context) error { select {} },
+			},
+			wantStatus: 503,
+			wantFailed: []string{"database"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.New(50 * time.Millisecond)
+			for name, check := range tt.checks {
+				checker.Add(name, check)
+			}
+			rec := httptest.NewRecorder()
+			checker.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/api/healthz/ready", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			// Why a check failed is only logged.
+			if strings.Contains(rec.Body.String(), "connection refused") {
+				t.Errorf("the report %s gives the check's error away", rec.Body)
+			}
+			var report health.Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d checks, want %d", len(report.Checks), len(tt.checks))
+			}
+			for _, name := range tt.wantFailed {
+				if res := report.Checks[name]; res.Status != health.StatusFail || res.Error == "" {
+					t.Errorf("check %s = %+v, want it failed with an error", name, res)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/logging"
+	"github.com/google/uuid"
+)
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		value      string
+		wantRedact bool
+	}{
+		{name: "refresh token", key: "refresh_token", value: "foobar", wantRedact: true},
+		{name: "password", key: "password", value: "foobar", wantRedact: true},
+		{name: "authorization header", key: "Authorization", value: "Bearer foobar", wantRedact: true},
+		{name: "api key", key: "polka_api_key", value: "foobar", wantRedact: true},
+		{name: "plain value", key: "email", value: "foo@bar.com", wantRedact: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logging.New(&buf, slog.LevelInfo)
+			logger.Info("test", tt.key, tt.value)
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("log entry isn't JSON: %v", err)
+			}
+			got := entry[tt.key]
+			if tt.wantRedact && got != logging.Redacted {
+				t.Errorf("%s = %v, want it redacted", tt.key, got)
+			}
+			if !tt.wantRedact && got != tt.value {
+				t.Errorf("%s = %v, want %s", tt.key, got, tt.value)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{name: "propagates request id", requestID: "abc-123", wantRequestID: "abc-123"},
+		{name: "generates request id", requestID: "", wantRequestID: ""},
+		{name: "replaces invalid request id", requestID: "bad id\n", wantRequestID: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logging.New(&buf, slog.LevelInfo)
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+				logging.SetUserID(r.Context(), userID)
+				logging.SetError(w, errors.New("chirp not found"))
+				w.WriteHeader(404)
+			})
+			req := httptest.NewRequest("GET", "/api/chirps/123", nil)
+			req.Header.Set("Authorization", "Bearer foobar")
+			if tt.requestID != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.requestID)
+			}
+			res := httptest.NewRecorder()
+			logging.Middleware(logger, mux).ServeHTTP(res, req)
+
+			gotID := res.Header().Get(logging.RequestIDHeader)
+			if gotID == "" {
+				t.Fatal("response has no request id")
+			}
+			if tt.wantRequestID != "" && gotID != tt.wantRequestID {
+				t.Errorf("request id = %s, want %s", gotID, tt.wantRequestID)
+			}
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("log entry isn't JSON: %v", err)
+			}
+			want := map[string]any{
+				"request_id": gotID,
+				"method":     "GET",
+				"route":      "GET /api/chirps/{chirpID}",
+				"status":     float64(404),
+				"user_id":    userID.String(),
+				"error":      "chirp not found",
+			}
+			for k, v := range want {
+				if entry[k] != v {
+					t.Errorf("%s = %v, want %v", k, entry[k], v)
+				}
+			}
+			if strings.Contains(buf.String(), "foobar") {
+				t.Errorf("log entry leaks the bearer token: %s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/recorder"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type contextKey struct{}
+
+// requestInfo is shared between the middleware and the handler it wraps, so
+// the handler can report who the request was made by.
+type requestInfo struct {
+	id string
+	mu sync.Mutex
+	// user is filled in by the handler once the request is authenticated.
+	user string
+}
+
+func (i *requestInfo) userID() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.user
+}
+
+func requestInfoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(contextKey{}).(*requestInfo)
+	return info
+}
+
+// RequestID returns the ID of the request being served, or "".
+func RequestID(ctx context.Context) string {
+	if info := requestInfoFrom(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID records the authenticated user on the request's log entries.
+func SetUserID(ctx context.Context, userID uuid.UUID) {
+	if info := requestInfoFrom(ctx); info != nil {
+		info.mu.Lock()
+		info.user = userID.String()
+		info.mu.Unlock()
+	}
+}
+
+// SetError attaches an error to the access log entry of the request being
+// answered through w.
+func SetError(w http.ResponseWriter, err error) {
+	recorder.SetError(w, err)
+}
+
+// Middleware assigns every request an ID, propagating a valid X-Request-ID
+// from the client, and writes one log entry per request with its method,
+// route pattern, status, latency and user.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		info := &requestInfo{id: id}
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, info))
+		w.Header().Set(RequestIDHeader, id)
+		rec := recorder.Wrap(w)
+
+		next.ServeHTTP(rec, r)
+
+		status := rec.Status()
+		attrs := []any{
+			slog.String("method", r.Method),
+			slog.String("route", rec.Pattern(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+		}
+		level := slog.LevelInfo
+		if err := rec.Err(); err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		logger.Log(r.Context(), level, "request", attrs...)
+	})
+}
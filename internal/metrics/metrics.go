@@ -0,0 +1,153 @@
+// Package metrics exposes the server's Prometheus metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/recorder"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chirpy"
+
+// Login results.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Polka webhook outcomes.
+const (
+	WebhookUnauthorized = "unauthorized"
+	WebhookBadRequest   = "bad_request"
+	WebhookIgnored      = "ignored"
+	WebhookNotFound     = "not_found"
+	WebhookUpgraded     = "upgraded"
+)
+
+// Registry holds every chirpy metric along with the Go runtime and process
+// collectors. It's separate from the prometheus default registry so tests
+// and libraries can't leak metrics into /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route pattern, method and status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by route pattern, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time taken by database queries, by sqlc query name.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"query"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts, by result.",
+	}, []string{"result"})
+
+	chirpsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chirps_created_total",
+		Help:      "Chirps created, by status at creation.",
+	}, []string{"status"})
+
+	webhooks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "polka_webhooks_total",
+		Help:      "Polka webhooks received, by event and outcome.",
+	}, []string{"event", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		dbQueryDuration,
+		logins,
+		chirpsCreated,
+		webhooks,
+	)
+	for _, result := range []string{LoginSuccess, LoginFailure} {
+		logins.WithLabelValues(result)
+	}
+}
+
+// Handler serves the metrics in Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware counts and times every request. Requests are labelled with the
+// ServeMux pattern that matched them rather than their path, so path
+// parameters don't blow up the label cardinality.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := recorder.Wrap(w)
+
+		next.ServeHTTP(rec, r)
+
+		route := rec.Pattern(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(rec.Status())
+		httpRequests.WithLabelValues(route, r.Method, status).Inc()
+		httpDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveQuery records how long the named database query took.
+func ObserveQuery(query string, d time.Duration) {
+	dbQueryDuration.WithLabelValues(query).Observe(d.Seconds())
+}
+
+// Login counts a login attempt with the given result.
+func Login(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+// ChirpCreated counts a new chirp with the given status.
+func ChirpCreated(status string) {
+	chirpsCreated.WithLabelValues(status).Inc()
+}
+
+// Webhook counts a Polka webhook with the given event and outcome. The event
+// comes from the request body, so anything but user.upgraded is counted as
+// "other" to keep the label set bounded.
+func Webhook(event, outcome string) {
+	if event != "" && event != "user.upgraded" {
+		event = "other"
+	}
+	webhooks.WithLabelValues(event, outcome).Inc()
+}
+
+// RegisterFileServerHits exposes hits, the number of requests served by the
+// /app file server, as a counter.
+func RegisterFileServerHits(hits func() uint64) {
+	Registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fileserver_hits_total",
+		Help:      "Requests served by the /app file server.",
+	}, func() float64 {
+		return float64(hits())
+	}))
+}
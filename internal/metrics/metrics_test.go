@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMiddlewareLabelsByPattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := metrics.Middleware(mux)
+	for _, path := range []string{"/api/chirps/1", "/api/chirps/2", "/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	body := scrape(t)
+	for _, want := range []string{
+		`chirpy_http_requests_total{method="GET",route="GET /api/chirps/{chirpID}",status="404"} 2`,
+		`chirpy_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="GET",route="GET /api/chirps/{chirpID}",status="404"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
+
+func TestFileServerHits(t *testing.T) {
+	var hits uint64 = 1 << 40
+	metrics.RegisterFileServerHits(func() uint64 { return hits })
+
+	if want := "chirpy_fileserver_hits_total 1.099511627776e+12"; !strings.Contains(scrape(t), want) {
+		t.Errorf("metrics missing %q", want)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	metrics.Login(metrics.LoginFailure)
+	metrics.ChirpCreated("draft")
+	metrics.Webhook("user.upgraded", metrics.WebhookNotFound)
+	metrics.Webhook("user.downgraded", metrics.WebhookIgnored)
+	metrics.Webhook("subscription.cancelled", metrics.WebhookIgnored)
+	metrics.ObserveQuery("GetChirpByID", 0)
+
+	body := scrape(t)
+	for _, want := range []string{
+		`chirpy_logins_total{result="failure"} 1`,
+		`chirpy_logins_total{result="success"} 0`,
+		`chirpy_chirps_created_total{status="draft"} 1`,
+		`chirpy_polka_webhooks_total{event="user.upgraded",outcome="not_found"} 1`,
+		`chirpy_polka_webhooks_total{event="other",outcome="ignored"} 2`,
+		`chirpy_db_query_duration_seconds_count{query="GetChirpByID"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Type identifies the event a notification was raised for. Users can turn
+// individual types off through their notification preferences.
+type Type string
+
+const (
+	TypeChirpyRed    Type = "chirpy_red"
+	TypeNewLogin     Type = "new_login"
+	TypeChirpRemoved Type = "chirp_removed"
+)
+
+var Types = []Type{
+	TypeChirpyRed,
+	TypeNewLogin,
+	TypeChirpRemoved,
+}
+
+var (
+	ErrQueueFull = errors.New("notification queue is full")
+	ErrClosed    = errors.New("notifier is closed")
+)
+
+func ValidType(t Type) bool {
+	for _, v := range Types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+type Store interface {
+	AddNotification(ctx context.Context, arg database.AddNotificationParams) error
+}
+
+type job struct {
+	kind       Type
+	payload    json.RawMessage
+	recipients []uuid.UUID
+}
+
+// Notifier fans notifications out to their recipients on background workers,
+// so callers only pay for queueing the event.
+type Notifier struct {
+	store   Store
+	jobs    chan job
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+	timeout time.Duration
+	workers atomic.Int32
+}
+
+func New(store Store, queueSize int) *Notifier {
+	return &Notifier{
+		store:   store,
+		jobs:    make(chan job, queueSize),
+		timeout: 5 * time.Second,
+	}
+}
+
+// Start launches the given number of workers writing queued notifications.
+func (n *Notifier) Start(workers int) {
+	for range max(workers, 1) {
+		n.wg.Add(1)
+		n.workers.Add(1)
+		go func() {
+			defer n.wg.Done()
+			defer n.workers.Add(-1)
+			for j := range n.jobs {
+				n.deliver(j)
+			}
+		}()
+	}
+}
+
+// Close stops accepting notifications and waits for the queue to drain.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.jobs)
+	}
+	n.mu.Unlock()
+	n.wg.Wait()
+}
+
+// Healthy reports whether notifications are being delivered: the notifier
+// must be open, with workers running and room left in its queue.
+func (n *Notifier) Healthy() error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return ErrClosed
+	}
+	if n.workers.Load() == 0 {
+		return errors.New("no workers running")
+	}
+	if len(n.jobs) == cap(n.jobs) {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// Notify queues a notification of type t for every recipient. The payload is
+// stored as JSON. It never blocks; ErrQueueFull is returned when the workers
+// are falling behind.
+func (n *Notifier) Notify(t Type, payload any, recipients ...uuid.UUID) error {
+	if !ValidType(t) {
+		return errors.New("unknown notification type: " + string(t))
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+	if payload == nil {
+		payload = struct{}{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.closed {
+		return ErrClosed
+	}
+	select {
+	case n.jobs <- job{kind: t, payload: data, recipients: recipients}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (n *Notifier) deliver(j job) {
+	for _, id := range j.recipients {
+		ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+		err := n.store.AddNotification(ctx, database.AddNotificationParams{
+			UserID:  id,
+			Type:    string(j.kind),
+			Payload: j.payload,
+		})
+		cancel()
+		if err != nil {
+			slog.Error("error adding notification", "type", j.kind, "user_id", id, "error", err)
+		}
+	}
+}
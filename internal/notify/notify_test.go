@@ -0,0 +1,104 @@
+package notify_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/google/uuid"
+)
+
+type fakeStore struct {
+	mu   sync.Mutex
+	rows []database.AddNotificationParams
+}
+
+func (s *fakeStore) AddNotification(ctx context.Context, arg database.AddNotificationParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows = append(s.rows, arg)
+	return nil
+}
+
+func TestNotify(t *testing.T) {
+	recipients := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	tests := []struct {
+		name       string
+		kind       notify.Type
+		payload    any
+		recipients []uuid.UUID
+		wantRows   int
+		wantErr    bool
+	}{
+		{
+			name:       "fan out",
+			kind:       notify.TypeNewLogin,
+			payload:    map[string]string{"ip": "127.0.0.1"},
+			recipients: recipients,
+			wantRows:   3,
+			wantErr:    false,
+		},
+		{
+			name:       "nil payload",
+			kind:       notify.TypeChirpyRed,
+			payload:    nil,
+			recipients: recipients[:1],
+			wantRows:   1,
+			wantErr:    false,
+		},
+		{
+			name:       "unknown type",
+			kind:       notify.Type("foobar"),
+			recipients: recipients,
+			wantRows:   0,
+			wantErr:    true,
+		},
+		{
+			name:       "no recipients",
+			kind:       notify.TypeChirpRemoved,
+			recipients: nil,
+			wantRows:   0,
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{}
+			n := notify.New(store, 8)
+			n.Start(2)
+			gotErr := n.Notify(tt.kind, tt.payload, tt.recipients...)
+			n.Close()
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Fatalf("Notify failed unexpectedly: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("Notify succeeded unexpectedly")
+			}
+			if len(store.rows) != tt.wantRows {
+				t.Fatalf("Notify wrote %d rows, want %d", len(store.rows), tt.wantRows)
+			}
+			for _, row := range store.rows {
+				if row.Type != string(tt.kind) {
+					t.Errorf("row type = %s, want %s", row.Type, tt.kind)
+				}
+				if len(row.Payload) == 0 {
+					t.Errorf("row payload is empty")
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyAfterClose(t *testing.T) {
+	n := notify.New(&fakeStore{}, 1)
+	n.Start(1)
+	n.Close()
+	if err := n.Notify(notify.TypeNewLogin, nil, uuid.New()); err == nil {
+		t.Fatal("Notify succeeded on a closed notifier")
+	}
+}
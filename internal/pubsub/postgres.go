@@ -0,0 +1,98 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostgresBus is a Bus backed by Postgres LISTEN/NOTIFY, so every instance
+// connected to the same database sees every published message.
+type PostgresBus struct {
+	*hub
+	db       *sql.DB
+	listener *pq.Listener
+	mu       sync.Mutex
+	channels map[string]bool
+	done     chan struct{}
+	// connected tracks whether the listener has a connection, for Healthy.
+	connected atomic.Bool
+}
+
+func NewPostgresBus(db *sql.DB, dbURL string) *PostgresBus {
+	b := &PostgresBus{
+		hub:      newHub(),
+		db:       db,
+		channels: map[string]bool{},
+		done:     make(chan struct{}),
+	}
+	b.listener = pq.NewListener(dbURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+			b.connected.Store(true)
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			b.connected.Store(false)
+		}
+		if err != nil {
+			slog.Error("pubsub listener error", "error", err)
+		}
+	})
+	go b.run()
+	return b
+}
+
+func (b *PostgresBus) run() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case n := <-b.listener.NotificationChannel():
+			// A nil notification means the connection was re-established and
+			// notifications may have been lost in between.
+			if n == nil {
+				continue
+			}
+			b.dispatch(n.Channel, []byte(n.Extra))
+		case <-time.After(90 * time.Second):
+			go b.listener.Ping()
+		}
+	}
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, channel string, payload []byte) error {
+	_, err := b.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, string(payload))
+	return err
+}
+
+func (b *PostgresBus) Subscribe(channel string) (<-chan []byte, func()) {
+	b.mu.Lock()
+	if !b.channels[channel] {
+		if err := b.listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+			slog.Error("pubsub couldn't listen on channel", "channel", channel, "error", err)
+		} else {
+			b.channels[channel] = true
+		}
+	}
+	b.mu.Unlock()
+	return b.hub.Subscribe(channel)
+}
+
+// Healthy reports whether the bus is connected to Postgres and receiving
+// notifications.
+func (b *PostgresBus) Healthy() error {
+	if !b.connected.Load() {
+		return errors.New("listener is disconnected")
+	}
+	return nil
+}
+
+func (b *PostgresBus) Close() error {
+	close(b.done)
+	return b.listener.Close()
+}
@@ -0,0 +1,77 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus delivers messages published on a channel to every subscriber of that
+// channel, possibly across several server instances.
+type Bus interface {
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe returns a channel of payloads and a function that ends the
+	// subscription. Slow subscribers miss messages rather than block the bus.
+	Subscribe(channel string) (<-chan []byte, func())
+	Close() error
+}
+
+const subscriberBuffer = 64
+
+// hub fans messages out to local subscribers. Bus implementations embed it
+// and feed it from their transport.
+type hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan []byte]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: map[string]map[chan []byte]struct{}{}}
+}
+
+func (h *hub) Subscribe(channel string) (<-chan []byte, func()) {
+	ch := make(chan []byte, subscriberBuffer)
+	h.mu.Lock()
+	if h.subs[channel] == nil {
+		h.subs[channel] = map[chan []byte]struct{}{}
+	}
+	h.subs[channel][ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[channel], ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *hub) dispatch(channel string, payload []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[channel] {
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+}
+
+// MemoryBus is a Bus for a single process.
+type MemoryBus struct {
+	*hub
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{hub: newHub()}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, channel string, payload []byte) error {
+	b.dispatch(channel, payload)
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	return nil
+}
@@ -0,0 +1,47 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/pubsub"
+)
+
+func TestMemoryBus(t *testing.T) {
+	bus := pubsub.NewMemoryBus()
+	chirps, stopChirps := bus.Subscribe("chirps")
+	defer stopChirps()
+	other, stopOther := bus.Subscribe("other")
+	defer stopOther()
+
+	if err := bus.Publish(context.Background(), "chirps", []byte("foobar")); err != nil {
+		t.Fatalf("Publish failed unexpectedly: %v", err)
+	}
+	select {
+	case got := <-chirps:
+		if string(got) != "foobar" {
+			t.Fatalf("got payload %s, want foobar", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber didn't receive the message")
+	}
+	select {
+	case got := <-other:
+		t.Fatalf("subscriber on another channel received %s", got)
+	default:
+	}
+}
+
+func TestMemoryBusUnsubscribe(t *testing.T) {
+	bus := pubsub.NewMemoryBus()
+	ch, stop := bus.Subscribe("chirps")
+	stop()
+	stop()
+	if err := bus.Publish(context.Background(), "chirps", []byte("foobar")); err != nil {
+		t.Fatalf("Publish failed unexpectedly: %v", err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("unsubscribed channel received a message")
+	}
+}
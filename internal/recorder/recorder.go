@@ -0,0 +1,124 @@
+package recorder
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Recorder wraps a ResponseWriter to capture what a handler answered with,
+// for middleware that logs or measures requests. It keeps the Flusher and
+// Hijacker interfaces of the wrapped writer for streaming endpoints.
+type Recorder struct {
+	http.ResponseWriter
+	status  int
+	pattern string
+	mu      sync.Mutex
+	err     error
+}
+
+// Wrap returns the Recorder already wrapping w, or a new one, so several
+// middlewares share a single Recorder.
+func Wrap(w http.ResponseWriter) *Recorder {
+	if rec, ok := w.(*Recorder); ok {
+		return rec
+	}
+	return &Recorder{ResponseWriter: w}
+}
+
+// Status returns the status code written, 200 if the handler wrote none.
+func (rec *Recorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// Pattern returns the ServeMux pattern that matched r. Middlewares that
+// replace the request with a copy, to add to its context, hide the pattern
+// the mux sets from the middlewares around them, so it's taken from Route if
+// the mux is wrapped in one.
+func (rec *Recorder) Pattern(r *http.Request) string {
+	if rec.pattern != "" {
+		return rec.pattern
+	}
+	return r.Pattern
+}
+
+// Route wraps a ServeMux so that the pattern it matches is available from
+// the Recorder of any middleware around it.
+func Route(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if rec := find(w); rec != nil {
+			rec.pattern = r.Pattern
+		}
+	})
+}
+
+// Err returns the error recorded with SetError.
+func (rec *Recorder) Err() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.err
+}
+
+// SetError records the error a handler answered w with. It does nothing if w
+// isn't wrapped by a Recorder.
+func SetError(w http.ResponseWriter, err error) {
+	if rec := find(w); rec != nil {
+		rec.mu.Lock()
+		rec.err = err
+		rec.mu.Unlock()
+	}
+}
+
+func find(w http.ResponseWriter) *Recorder {
+	for {
+		switch v := w.(type) {
+		case *Recorder:
+			return v
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+func (rec *Recorder) WriteHeader(statusCode int) {
+	if rec.status == 0 {
+		rec.status = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *Recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *Recorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rec *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+func (rec *Recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
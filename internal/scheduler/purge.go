@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type PurgeStore interface {
+	PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error)
+}
+
+// Purger permanently removes soft deleted chirps once their undo window has
+// passed, and accounts whose deletion grace period is over.
+type Purger struct {
+	store        PurgeStore
+	interval     time.Duration
+	chirpWindow  time.Duration
+	accountGrace time.Duration
+	lastRun      atomic.Int64
+}
+
+func NewPurger(store PurgeStore, interval, chirpWindow, accountGrace time.Duration) *Purger {
+	return &Purger{
+		store:        store,
+		interval:     interval,
+		chirpWindow:  chirpWindow,
+		accountGrace: accountGrace,
+	}
+}
+
+// Run purges expired rows every interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Purge(ctx, time.Now()); err != nil {
+			if ctx.Err() == nil {
+				slog.Error("error purging deleted data", "error", err)
+			}
+		} else {
+			p.lastRun.Store(time.Now().UnixNano())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRun returns when Run last purged successfully, or the zero time if it
+// hasn't yet.
+func (p *Purger) LastRun() time.Time {
+	return unixNano(p.lastRun.Load())
+}
+
+// Interval returns how often Run purges.
+func (p *Purger) Interval() time.Duration {
+	return p.interval
+}
+
+func (p *Purger) Purge(ctx context.Context, now time.Time) error {
+	chirps, err := p.store.PurgeDeletedChirps(ctx, sql.NullTime{Time: now.Add(-p.chirpWindow).UTC(), Valid: true})
+	if err != nil {
+		return err
+	}
+	users, err := p.store.PurgeDeletedUsers(ctx, sql.NullTime{Time: now.Add(-p.accountGrace).UTC(), Valid: true})
+	if err != nil {
+		return err
+	}
+	if chirps > 0 || users > 0 {
+		slog.Info("purged deleted data", "chirps", chirps, "users", users)
+	}
+	return nil
+}
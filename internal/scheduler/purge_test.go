@@ -0,0 +1,70 @@
+package scheduler_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/scheduler"
+)
+
+type fakePurgeStore struct {
+	chirpsBefore sql.NullTime
+	usersBefore  sql.NullTime
+	err          error
+	// onCall, if set, is called whenever chirps are purged.
+	onCall func()
+}
+
+func (s *fakePurgeStore) PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	s.chirpsBefore = deletedBefore
+	if s.onCall != nil {
+		s.onCall()
+	}
+	return 0, s.err
+}
+
+func (s *fakePurgeStore) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	s.usersBefore = deletedBefore
+	return 0, nil
+}
+
+func TestPurge(t *testing.T) {
+	store := &fakePurgeStore{}
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	p := scheduler.NewPurger(store, time.Minute, 10*time.Minute, 48*time.Hour)
+	if err := p.Purge(context.Background(), now); err != nil {
+		t.Fatalf("Purge failed unexpectedly: %v", err)
+	}
+	if want := now.Add(-10 * time.Minute); !store.chirpsBefore.Valid || !store.chirpsBefore.Time.Equal(want) {
+		t.Errorf("chirps purged before %v, want %v", store.chirpsBefore.Time, want)
+	}
+	if want := now.Add(-48 * time.Hour); !store.usersBefore.Valid || !store.usersBefore.Time.Equal(want) {
+		t.Errorf("users purged before %v, want %v", store.usersBefore.Time, want)
+	}
+}
+
+func TestPurgerRunRecordsLastRun(t *testing.T) {
+	// run runs p until it has purged once.
+	run := func(p *scheduler.Purger, store *fakePurgeStore) {
+		ctx, cancel := context.WithCancel(context.Background())
+		store.onCall = cancel
+		p.Run(ctx)
+	}
+
+	failing := &fakePurgeStore{err: errors.New("database is down")}
+	p := scheduler.NewPurger(failing, time.Hour, time.Minute, time.Hour)
+	run(p, failing)
+	if !p.LastRun().IsZero() {
+		t.Error("LastRun is set after Run failed to purge")
+	}
+
+	working := &fakePurgeStore{}
+	p = scheduler.NewPurger(working, time.Hour, time.Minute, time.Hour)
+	run(p, working)
+	if p.LastRun().IsZero() {
+		t.Error("LastRun isn't set after Run purged")
+	}
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+)
+
+const batchSize = 100
+
+type Store interface {
+	PublishDueChirps(ctx context.Context, batchSize int32) ([]database.Chirp, error)
+}
+
+// Scheduler publishes scheduled chirps once their publish time has passed.
+// Several replicas can run one each: PublishDueChirps claims due rows with
+// FOR UPDATE SKIP LOCKED and flips their status in the same statement, so
+// every chirp is published by exactly one of them.
+type Scheduler struct {
+	store     Store
+	interval  time.Duration
+	onPublish func(ctx context.Context, c database.Chirp)
+	lastRun   atomic.Int64
+}
+
+func New(store Store, interval time.Duration, onPublish func(ctx context.Context, c database.Chirp)) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		interval:  interval,
+		onPublish: onPublish,
+	}
+}
+
+// Run publishes due chirps every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.PublishDue(ctx); err != nil {
+			if ctx.Err() == nil {
+				slog.Error("error publishing scheduled chirps", "error", err)
+			}
+		} else {
+			s.lastRun.Store(time.Now().UnixNano())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastRun returns when Run last looked for due chirps successfully, or the zero
+// time if it hasn't yet.
+func (s *Scheduler) LastRun() time.Time {
+	return unixNano(s.lastRun.Load())
+}
+
+// Interval returns how often Run looks for due chirps.
+func (s *Scheduler) Interval() time.Duration {
+	return s.interval
+}
+
+func unixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// PublishDue publishes every chirp that is currently due.
+func (s *Scheduler) PublishDue(ctx context.Context) error {
+	for {
+		chirps, err := s.store.PublishDueChirps(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+		for _, c := range chirps {
+			if s.onPublish != nil {
+				s.onPublish(ctx, c)
+			}
+		}
+		if len(chirps) < batchSize {
+			return nil
+		}
+	}
+}
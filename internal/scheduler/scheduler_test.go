@@ -0,0 +1,134 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/scheduler"
+	"github.com/google/uuid"
+)
+
+type fakeStore struct {
+	due   []database.Chirp
+	err   error
+	calls int
+	// onCall, if set, is called whenever the store is.
+	onCall func()
+}
+
+func (s *fakeStore) PublishDueChirps(ctx context.Context, batchSize int32) ([]database.Chirp, error) {
+	s.calls++
+	if s.onCall != nil {
+		s.onCall()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	n := min(int(batchSize), len(s.due))
+	batch := s.due[:n]
+	s.due = s.due[n:]
+	return batch, nil
+}
+
+func TestPublishDue(t *testing.T) {
+	makeChirps := func(n int) []database.Chirp {
+		chirps := make([]database.Chirp, n)
+		for i := range chirps {
+			chirps[i] = database.Chirp{ID: uuid.New(), Status: "published"}
+		}
+		return chirps
+	}
+	tests := []struct {
+		name          string
+		store         *fakeStore
+		wantPublished int
+		wantCalls     int
+		wantErr       bool
+	}{
+		{
+			name:          "nothing due",
+			store:         &fakeStore{},
+			wantPublished: 0,
+			wantCalls:     1,
+			wantErr:       false,
+		},
+		{
+			name:          "one batch",
+			store:         &fakeStore{due: makeChirps(3)},
+			wantPublished: 3,
+			wantCalls:     1,
+			wantErr:       false,
+		},
+		{
+			name:          "several batches",
+			store:         &fakeStore{due: makeChirps(250)},
+			wantPublished: 250,
+			wantCalls:     3,
+			wantErr:       false,
+		},
+		{
+			name:      "store error",
+			store:     &fakeStore{err: errors.New("foobar")},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			published := map[uuid.UUID]int{}
+			s := scheduler.New(tt.store, time.Minute, func(ctx context.Context, c database.Chirp) {
+				published[c.ID]++
+			})
+			gotErr := s.PublishDue(context.Background())
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Fatalf("PublishDue failed unexpectedly: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("PublishDue succeeded unexpectedly")
+			}
+			if len(published) != tt.wantPublished {
+				t.Errorf("published %d chirps, want %d", len(published), tt.wantPublished)
+			}
+			for id, n := range published {
+				if n != 1 {
+					t.Errorf("chirp %v published %d times", id, n)
+				}
+			}
+			if tt.store.calls != tt.wantCalls {
+				t.Errorf("store called %d times, want %d", tt.store.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRunRecordsLastRun(t *testing.T) {
+	// run runs s until the store has been called once.
+	run := func(s *scheduler.Scheduler, store *fakeStore) {
+		ctx, cancel := context.WithCancel(context.Background())
+		store.onCall = cancel
+		s.Run(ctx)
+	}
+
+	failing := &fakeStore{err: errors.New("database is down")}
+	s := scheduler.New(failing, time.Hour, nil)
+	if !s.LastRun().IsZero() {
+		t.Fatal("LastRun is set before Run")
+	}
+	run(s, failing)
+	if !s.LastRun().IsZero() {
+		t.Error("LastRun is set after Run failed to look for due chirps")
+	}
+
+	working := &fakeStore{}
+	s = scheduler.New(working, time.Hour, nil)
+	run(s, working)
+	if s.LastRun().IsZero() {
+		t.Error("LastRun isn't set after Run looked for due chirps")
+	}
+}
@@ -0,0 +1,251 @@
+// Package chirps holds the rules for posting, listing and deleting chirps:
+// how long they can be, which words are censored, who they can mention and
+// who can delete them.
+package chirps
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+)
+
+// A chirp's status. Drafts and scheduled chirps are only visible to their
+// author.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
+// MaxLength is the longest a chirp can be, in characters as a reader counts
+// them, so "café" and "👍🏽" cost the same as ASCII.
+const MaxLength = 140
+
+// ErrNotFound is returned for a chirp that doesn't exist or that the user
+// can't see.
+var ErrNotFound = apierror.New(404, apierror.CodeNotFound, "chirp not found")
+
+// errNotUnpublished is returned for publishing a chirp that isn't one of the
+// user's drafts or scheduled chirps.
+var errNotUnpublished = apierror.New(404, apierror.CodeNotFound, "no draft or scheduled chirp with that id")
+
+// Service posts, lists and deletes chirps. Problems with the request are
+// *apierror.Errors; any other error is an internal one.
+type Service interface {
+	// Prepare checks a new chirp by userID and censors it.
+	Prepare(ctx context.Context, userID uuid.UUID, body string) (string, error)
+	// Add publishes a chirp whose body has been through Prepare.
+	Add(ctx context.Context, userID uuid.UUID, body string) (database.Chirp, error)
+	// Publish publishes one of userID's drafts or scheduled chirps now. Its
+	// mentions are checked again, as someone it mentions may have blocked
+	// the author since it was written.
+	Publish(ctx context.Context, userID, chirpID uuid.UUID) (database.Chirp, error)
+	// Delete deletes one of userID's chirps. Another user's chirp is
+	// forbidden, and one the user can't see isn't found.
+	Delete(ctx context.Context, userID, chirpID uuid.UUID) error
+	// List lists published chirps, all of them or one author's, leaving out
+	// those the viewer can't or doesn't want to see. They're oldest first
+	// unless newestFirst is set.
+	List(ctx context.Context, viewer, authorID uuid.NullUUID, newestFirst bool) ([]database.Chirp, error)
+}
+
+// Store is the storage the service needs. *database.Queries is one.
+type Store interface {
+	IsBlockedByAnyEmail(ctx context.Context, arg database.IsBlockedByAnyEmailParams) (bool, error)
+	AddChirp(ctx context.Context, arg database.AddChirpParams) (database.Chirp, error)
+	PublishChirp(ctx context.Context, arg database.PublishChirpParams) (database.Chirp, error)
+	GetChirpByID(ctx context.Context, arg database.GetChirpByIDParams) (database.Chirp, error)
+	DeleteChirpByID(ctx context.Context, chirpID uuid.UUID) error
+	GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error)
+	GetChirpsFromUser(ctx context.Context, arg database.GetChirpsFromUserParams) ([]database.Chirp, error)
+}
+
+var _ Store = (*database.Queries)(nil)
+
+// Chirps is the Service backed by a Store. New chirps are handed to publish,
+// to be announced to streams.
+type Chirps struct {
+	store   Store
+	publish func(context.Context, database.Chirp)
+}
+
+func New(store Store, publish func(context.Context, database.Chirp)) *Chirps {
+	return &Chirps{store: store, publish: publish}
+}
+
+// ValidateBody checks a new chirp. A chirp that's too long is reported as
+// chirp_too_long, the code clients already handle.
+func ValidateBody(body string) error {
+	var v validate.Validator
+	v.Field("body", body, validate.Required, validate.MaxLength(MaxLength))
+	err := v.Err()
+	var e *apierror.Error
+	if errors.As(err, &e) && e.Fields[0].Code == "too_long" {
+		e.Status = 400
+		e.Code = "chirp_too_long"
+		e.Detail = "chirp is longer than 140 characters"
+	}
+	return err
+}
+
+var restrictedWords = [...]string{
+	"sharbert",
+	"kerfuffle",
+	"fornax",
+}
+
+// Censor masks restricted words in user submitted text.
+func Censor(body string) string {
+	bodyWords := strings.Split(body, " ")
+	for i, w := range bodyWords {
+		for _, word := range restrictedWords {
+			if strings.ToLower(w) == word {
+				bodyWords[i] = "****"
+			}
+		}
+	}
+	return strings.Join(bodyWords, " ")
+}
+
+// Mentions returns the accounts a chirp mentions. A mention is an email
+// address prefixed with @, e.g. "hi @walt@breakingbad.com!".
+func Mentions(body string) []string {
+	var emails []string
+	for _, word := range strings.Fields(body) {
+		if !strings.HasPrefix(word, "@") {
+			continue
+		}
+		email := strings.ToLower(strings.TrimRight(word[1:], ".,;:!?)\"'"))
+		if strings.Contains(email, "@") && !slices.Contains(emails, email) {
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+func (c *Chirps) Prepare(ctx context.Context, userID uuid.UUID, body string) (string, error) {
+	if err := ValidateBody(body); err != nil {
+		return "", err
+	}
+	if err := c.checkMentions(ctx, userID, body); err != nil {
+		return "", err
+	}
+	return Censor(body), nil
+}
+
+// checkMentions refuses a chirp by userID that mentions someone who has
+// blocked them.
+func (c *Chirps) checkMentions(ctx context.Context, userID uuid.UUID, body string) error {
+	mentions := Mentions(body)
+	if len(mentions) == 0 {
+		return nil
+	}
+	blocked, err := c.store.IsBlockedByAnyEmail(ctx, database.IsBlockedByAnyEmailParams{
+		UserID: userID,
+		Emails: mentions,
+	})
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return apierror.New(403, apierror.CodeForbidden, "chirp mentions a user who has blocked you")
+	}
+	return nil
+}
+
+func (c *Chirps) Add(ctx context.Context, userID uuid.UUID, body string) (database.Chirp, error) {
+	chirp, err := c.store.AddChirp(ctx, database.AddChirpParams{
+		ChirpBody: body,
+		ID:        userID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	metrics.ChirpCreated(StatusPublished)
+	if c.publish != nil {
+		c.publish(ctx, chirp)
+	}
+	return chirp, nil
+}
+
+func (c *Chirps) Publish(ctx context.Context, userID, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := c.store.GetChirpByID(ctx, database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) || err == nil && (chirp.UserID != userID || chirp.Status == StatusPublished) {
+		return database.Chirp{}, errNotUnpublished
+	}
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if err := c.checkMentions(ctx, userID, chirp.Body); err != nil {
+		return database.Chirp{}, err
+	}
+	chirp, err = c.store.PublishChirp(ctx, database.PublishChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// It was published or deleted since it was looked up.
+		return database.Chirp{}, errNotUnpublished
+	}
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	metrics.ChirpCreated(StatusPublished)
+	if c.publish != nil {
+		c.publish(ctx, chirp)
+	}
+	return chirp, nil
+}
+
+func (c *Chirps) Delete(ctx context.Context, userID, chirpID uuid.UUID) error {
+	chirp, err := c.store.GetChirpByID(ctx, database.GetChirpByIDParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if chirp.UserID != userID {
+		return apierror.New(403, apierror.CodeForbidden, "chirp belongs to another user")
+	}
+	return c.store.DeleteChirpByID(ctx, chirpID)
+}
+
+func (c *Chirps) List(ctx context.Context, viewer, authorID uuid.NullUUID, newestFirst bool) ([]database.Chirp, error) {
+	var chirps []database.Chirp
+	var err error
+	if authorID.Valid {
+		chirps, err = c.store.GetChirpsFromUser(ctx, database.GetChirpsFromUserParams{
+			AuthorID: authorID.UUID,
+			ViewerID: viewer,
+		})
+	} else {
+		chirps, err = c.store.GetAllChirps(ctx, viewer)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if newestFirst {
+		slices.SortFunc(chirps, func(a, b database.Chirp) int {
+			if a.CreatedAt.Before(b.CreatedAt) {
+				return 1
+			}
+			return -1
+		})
+	}
+	return chirps, nil
+}
@@ -0,0 +1,229 @@
+package chirps_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/service/chirps"
+	"github.com/google/uuid"
+)
+
+// fakeStore holds chirps in memory. Every chirp is visible to everyone, and
+// the users in blockers have blocked every author.
+type fakeStore struct {
+	chirps   []database.Chirp
+	blockers []string
+	deleted  []uuid.UUID
+}
+
+func (s *fakeStore) IsBlockedByAnyEmail(ctx context.Context, arg database.IsBlockedByAnyEmailParams) (bool, error) {
+	for _, email := range arg.Emails {
+		if slices.Contains(s.blockers, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *fakeStore) AddChirp(ctx context.Context, arg database.AddChirpParams) (database.Chirp, error) {
+	c := database.Chirp{ID: uuid.New(), CreatedAt: time.Now(), Body: arg.ChirpBody, UserID: arg.ID, Status: chirps.StatusPublished}
+	s.chirps = append(s.chirps, c)
+	return c, nil
+}
+
+func (s *fakeStore) PublishChirp(ctx context.Context, arg database.PublishChirpParams) (database.Chirp, error) {
+	for i, c := range s.chirps {
+		if c.ID == arg.ID && c.UserID == arg.UserID && c.Status != chirps.StatusPublished {
+			s.chirps[i].Status = chirps.StatusPublished
+			return s.chirps[i], nil
+		}
+	}
+	return database.Chirp{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) GetChirpByID(ctx context.Context, arg database.GetChirpByIDParams) (database.Chirp, error) {
+	for _, c := range s.chirps {
+		if c.ID == arg.ID {
+			return c, nil
+		}
+	}
+	return database.Chirp{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) DeleteChirpByID(ctx context.Context, chirpID uuid.UUID) error {
+	s.deleted = append(s.deleted, chirpID)
+	return nil
+}
+
+func (s *fakeStore) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	return slices.Clone(s.chirps), nil
+}
+
+func (s *fakeStore) GetChirpsFromUser(ctx context.Context, arg database.GetChirpsFromUserParams) ([]database.Chirp, error) {
+	var res []database.Chirp
+	for _, c := range s.chirps {
+		if c.UserID == arg.AuthorID {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func apiCode(err error) apierror.Code {
+	var e *apierror.Error
+	if !errors.As(err, &e) {
+		return ""
+	}
+	return e.Code
+}
+
+func TestPrepare(t *testing.T) {
+	store := &fakeStore{blockers: []string{"hank@dea.gov"}}
+	s := chirps.New(store, nil)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+		code apierror.Code
+	}{
+		{"plain", "say my name", "say my name", ""},
+		{"censored", "what a Kerfuffle today", "what a **** today", ""},
+		{"punctuation isn't censored", "kerfuffle!", "kerfuffle!", ""},
+		{"longest", strings.Repeat("é", chirps.MaxLength), strings.Repeat("é", chirps.MaxLength), ""},
+		{"too long", strings.Repeat("x", chirps.MaxLength+1), "", "chirp_too_long"},
+		{"empty", "", "", apierror.CodeValidation},
+		{"mentions a blocker", "hi @Hank@DEA.gov!", "", apierror.CodeForbidden},
+		{"mentions someone else", "hi @walt@breakingbad.com", "hi @walt@breakingbad.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Prepare(context.Background(), uuid.New(), tt.body)
+			if apiCode(err) != tt.code || (tt.code == "" && err != nil) {
+				t.Fatalf("err = %v, want code %q", err, tt.code)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	got := chirps.Mentions(`@walt@breakingbad.com, @WALT@breakingbad.com and "@jesse@breakingbad.com" @skyler`)
+	want := []string{"walt@breakingbad.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAddPublishes(t *testing.T) {
+	store := &fakeStore{}
+	var published []database.Chirp
+	s := chirps.New(store, func(ctx context.Context, c database.Chirp) { published = append(published, c) })
+	c, err := s.Add(context.Background(), uuid.New(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 1 || published[0].ID != c.ID {
+		t.Errorf("published %v, want %v", published, c)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	author := uuid.New()
+	draft := func(body string) database.Chirp {
+		return database.Chirp{ID: uuid.New(), Body: body, UserID: author, Status: chirps.StatusDraft}
+	}
+	mentioning, plain := draft("hi @hank@dea.gov"), draft("hello")
+	store := &fakeStore{blockers: []string{"hank@dea.gov"}, chirps: []database.Chirp{mentioning, plain}}
+	var published []database.Chirp
+	s := chirps.New(store, func(ctx context.Context, c database.Chirp) { published = append(published, c) })
+	ctx := context.Background()
+
+	if _, err := s.Publish(ctx, author, mentioning.ID); apiCode(err) != apierror.CodeForbidden {
+		t.Errorf("mentioning a blocker: err = %v, want forbidden", err)
+	}
+	if _, err := s.Publish(ctx, uuid.New(), plain.ID); apiCode(err) != apierror.CodeNotFound {
+		t.Errorf("another user's draft: err = %v, want not found", err)
+	}
+	c, err := s.Publish(ctx, author, plain.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Status != chirps.StatusPublished || len(published) != 1 || published[0].ID != plain.ID {
+		t.Errorf("got %+v, published %v", c, published)
+	}
+	if _, err := s.Publish(ctx, author, plain.ID); apiCode(err) != apierror.CodeNotFound {
+		t.Errorf("publishing twice: err = %v, want not found", err)
+	}
+}
+
+func TestDeleteChecksOwnership(t *testing.T) {
+	store := &fakeStore{}
+	s := chirps.New(store, nil)
+	ctx := context.Background()
+	owner := uuid.New()
+	c, _ := s.Add(ctx, owner, "mine")
+
+	if err := s.Delete(ctx, uuid.New(), c.ID); apiCode(err) != apierror.CodeForbidden {
+		t.Errorf("another user: err = %v, want forbidden", err)
+	}
+	if err := s.Delete(ctx, owner, uuid.New()); err != chirps.ErrNotFound {
+		t.Errorf("missing chirp: err = %v, want not found", err)
+	}
+	if err := s.Delete(ctx, owner, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(store.deleted, []uuid.UUID{c.ID}) {
+		t.Errorf("deleted %v, want only %v", store.deleted, c.ID)
+	}
+}
+
+func TestList(t *testing.T) {
+	walt, jesse := uuid.New(), uuid.New()
+	start := time.Now()
+	store := &fakeStore{chirps: []database.Chirp{
+		{ID: uuid.New(), UserID: walt, CreatedAt: start},
+		{ID: uuid.New(), UserID: jesse, CreatedAt: start.Add(time.Second)},
+		{ID: uuid.New(), UserID: walt, CreatedAt: start.Add(2 * time.Second)},
+	}}
+	s := chirps.New(store, nil)
+	ids := func(list []database.Chirp) []uuid.UUID {
+		var res []uuid.UUID
+		for _, c := range list {
+			res = append(res, c.ID)
+		}
+		return res
+	}
+	all := store.chirps
+
+	tests := []struct {
+		name        string
+		author      uuid.NullUUID
+		newestFirst bool
+		want        []uuid.UUID
+	}{
+		{"all", uuid.NullUUID{}, false, []uuid.UUID{all[0].ID, all[1].ID, all[2].ID}},
+		{"newest first", uuid.NullUUID{}, true, []uuid.UUID{all[2].ID, all[1].ID, all[0].ID}},
+		{"by author", uuid.NullUUID{UUID: walt, Valid: true}, true, []uuid.UUID{all[2].ID, all[0].ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.List(context.Background(), uuid.NullUUID{}, tt.author, tt.newestFirst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ids(got), tt.want) {
+				t.Errorf("got %v, want %v", ids(got), tt.want)
+			}
+		})
+	}
+}
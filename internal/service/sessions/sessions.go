@@ -0,0 +1,186 @@
+// Package sessions logs users in and issues their access and refresh
+// tokens.
+package sessions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+)
+
+// AccessTokenTTL is how long access tokens are valid.
+const AccessTokenTTL = time.Hour
+
+// ErrInvalidCredentials is returned for any failed login, whatever was wrong.
+var ErrInvalidCredentials = apierror.New(401, apierror.CodeInvalidCredentials, "incorrect email or password")
+
+// Service logs users in. Problems with the request are *apierror.Errors;
+// any other error is an internal one.
+type Service interface {
+	// Login checks a user's email and password and issues them an access
+	// token and a refresh token. Logging in cancels a pending account
+	// deletion.
+	Login(ctx context.Context, email, password string, client Client) (Session, error)
+	// Refresh issues a new access token for a refresh token.
+	Refresh(ctx context.Context, refreshToken string) (string, error)
+	// Revoke revokes a refresh token.
+	Revoke(ctx context.Context, refreshToken string) error
+}
+
+// Store is the storage the service needs. *database.Queries is one.
+type Store interface {
+	GetUserByEmail(ctx context.Context, email string) (database.GetUserByEmailRow, error)
+	GetHashedPasswordByID(ctx context.Context, id uuid.UUID) (string, error)
+	AddRefreshToken(ctx context.Context, arg database.AddRefreshTokenParams) (database.RefreshToken, error)
+	GetUserByRefreshToken(ctx context.Context, token string) (database.GetUserByRefreshTokenRow, error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+}
+
+var _ Store = (*database.Queries)(nil)
+
+// Notifier queues notifications. *notify.Notifier is one.
+type Notifier interface {
+	Notify(t notify.Type, payload any, recipients ...uuid.UUID) error
+}
+
+// Client describes where a login came from, for the new login notification.
+type Client struct {
+	UserAgent  string
+	RemoteAddr string
+}
+
+// Session is a logged in user and their new tokens.
+type Session struct {
+	User         database.GetUserByEmailRow
+	AccessToken  string
+	RefreshToken string
+}
+
+// Config is what the service needs besides its Store.
+type Config struct {
+	TokenSecret string
+	// DeletionGrace is how long a deleted account can still log in, which
+	// brings it back with CancelDeletion.
+	DeletionGrace  time.Duration
+	CancelDeletion func(ctx context.Context, userID uuid.UUID, deletedAt sql.NullTime) error
+	Notifier       Notifier
+}
+
+// Sessions is the Service backed by a Store.
+type Sessions struct {
+	store Store
+	cfg   Config
+}
+
+func New(store Store, cfg Config) *Sessions {
+	return &Sessions{store: store, cfg: cfg}
+}
+
+func (s *Sessions) Login(ctx context.Context, email, password string, client Client) (Session, error) {
+	var v validate.Validator
+	v.Field("email", email, validate.Required)
+	v.Field("password", password, validate.Required)
+	if err := v.Err(); err != nil {
+		return Session{}, err
+	}
+	row, err := s.store.GetUserByEmail(ctx, email)
+	if err != nil || row == (database.GetUserByEmailRow{}) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return Session{}, err
+		}
+		slog.InfoContext(ctx, "login failed: unknown email")
+		metrics.Login(metrics.LoginFailure)
+		return Session{}, ErrInvalidCredentials
+	}
+
+	if row.DeletedAt.Valid && time.Since(row.DeletedAt.Time) > s.cfg.DeletionGrace {
+		metrics.Login(metrics.LoginFailure)
+		return Session{}, ErrInvalidCredentials
+	}
+
+	hashedPass, err := s.store.GetHashedPasswordByID(ctx, row.ID)
+	if err != nil {
+		return Session{}, err
+	}
+	ok, err := auth.CheckPasswordHashContext(ctx, password, hashedPass)
+	if err != nil || !ok {
+		slog.InfoContext(ctx, "login failed: wrong password", "user_id", row.ID, "error", err)
+		metrics.Login(metrics.LoginFailure)
+		return Session{}, ErrInvalidCredentials
+	}
+	// Suspended users may still log in, to read; only a ban keeps them out.
+	if denylist.Status(row.Status) == denylist.Banned {
+		metrics.Login(metrics.LoginFailure)
+		return Session{}, apierror.Wrap(403, &denylist.Restriction{Status: denylist.Banned, Reason: row.StatusReason})
+	}
+	if row.DeletedAt.Valid {
+		if err := s.cfg.CancelDeletion(ctx, row.ID, row.DeletedAt); err != nil {
+			return Session{}, err
+		}
+	}
+	session := Session{User: row}
+	session.AccessToken, err = auth.MakeJWT(row.ID, s.cfg.TokenSecret, AccessTokenTTL)
+	if err != nil {
+		return Session{}, err
+	}
+	session.RefreshToken, err = auth.MakeRefreshToken()
+	if err != nil {
+		return Session{}, err
+	}
+	res, err := s.store.AddRefreshToken(ctx, database.AddRefreshTokenParams{
+		Token:  session.RefreshToken,
+		UserID: row.ID,
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	slog.InfoContext(ctx, "refresh token issued", "user_id", res.UserID, "expires_at", res.ExpiresAt)
+	metrics.Login(metrics.LoginSuccess)
+	if s.cfg.Notifier != nil {
+		if err := s.cfg.Notifier.Notify(notify.TypeNewLogin, map[string]string{
+			"user_agent":  client.UserAgent,
+			"remote_addr": client.RemoteAddr,
+		}, row.ID); err != nil {
+			slog.ErrorContext(ctx, "error queueing login notification", "error", err)
+		}
+	}
+	return session, nil
+}
+
+func (s *Sessions) Refresh(ctx context.Context, refreshToken string) (string, error) {
+	row, err := s.store.GetUserByRefreshToken(ctx, refreshToken)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", apierror.New(401, apierror.CodeUnauthorized, "unknown refresh token")
+	}
+	if err != nil {
+		return "", err
+	}
+	if !row.ExpiresAt.Valid {
+		panic("this should be unreachable; refresh token's expires at should never be null.")
+	}
+	if time.Now().After(row.ExpiresAt.Time) {
+		return "", apierror.New(401, apierror.CodeUnauthorized, "refresh token has expired")
+	}
+	if row.RevokedAt.Valid {
+		return "", apierror.New(401, apierror.CodeUnauthorized, "token has been revoked")
+	}
+	if denylist.Status(row.Status) == denylist.Banned {
+		return "", apierror.Wrap(403, &denylist.Restriction{Status: denylist.Banned})
+	}
+	return auth.MakeJWT(row.ID, s.cfg.TokenSecret, AccessTokenTTL)
+}
+
+func (s *Sessions) Revoke(ctx context.Context, refreshToken string) error {
+	return s.store.RevokeRefreshToken(ctx, refreshToken)
+}
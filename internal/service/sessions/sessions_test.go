@@ -0,0 +1,195 @@
+package sessions_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/service/sessions"
+	"github.com/google/uuid"
+)
+
+const secret = "a-secret-long-enough-for-the-tests"
+
+// fakeStore holds users by email, every one with the password "heisenberg1",
+// and refresh tokens by token.
+type fakeStore struct {
+	users   map[string]database.GetUserByEmailRow
+	hash    string
+	tokens  map[string]database.GetUserByRefreshTokenRow
+	issued  []database.AddRefreshTokenParams
+	revoked []string
+}
+
+func newFakeStore(t *testing.T) *fakeStore {
+	t.Helper()
+	hash, err := auth.HashPassword("heisenberg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeStore{
+		users:  map[string]database.GetUserByEmailRow{},
+		hash:   hash,
+		tokens: map[string]database.GetUserByRefreshTokenRow{},
+	}
+}
+
+func (s *fakeStore) addUser(email string, status denylist.Status, deletedAt sql.NullTime) uuid.UUID {
+	id := uuid.New()
+	s.users[email] = database.GetUserByEmailRow{ID: id, Email: email, Status: string(status), DeletedAt: deletedAt}
+	return id
+}
+
+func (s *fakeStore) GetUserByEmail(ctx context.Context, email string) (database.GetUserByEmailRow, error) {
+	row, ok := s.users[email]
+	if !ok {
+		return database.GetUserByEmailRow{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (s *fakeStore) GetHashedPasswordByID(ctx context.Context, id uuid.UUID) (string, error) {
+	return s.hash, nil
+}
+
+func (s *fakeStore) AddRefreshToken(ctx context.Context, arg database.AddRefreshTokenParams) (database.RefreshToken, error) {
+	s.issued = append(s.issued, arg)
+	return database.RefreshToken{Token: arg.Token, UserID: arg.UserID}, nil
+}
+
+func (s *fakeStore) GetUserByRefreshToken(ctx context.Context, token string) (database.GetUserByRefreshTokenRow, error) {
+	row, ok := s.tokens[token]
+	if !ok {
+		return database.GetUserByRefreshTokenRow{}, sql.ErrNoRows
+	}
+	return row, nil
+}
+
+func (s *fakeStore) RevokeRefreshToken(ctx context.Context, token string) error {
+	s.revoked = append(s.revoked, token)
+	return nil
+}
+
+type fakeNotifier struct {
+	sent []notify.Type
+}
+
+func (n *fakeNotifier) Notify(t notify.Type, payload any, recipients ...uuid.UUID) error {
+	n.sent = append(n.sent, t)
+	return nil
+}
+
+func apiCode(err error) apierror.Code {
+	var e *apierror.Error
+	if !errors.As(err, &e) {
+		return ""
+	}
+	return e.Code
+}
+
+func TestLogin(t *testing.T) {
+	store := newFakeStore(t)
+	notifier := &fakeNotifier{}
+	var cancelled []uuid.UUID
+	s := sessions.New(store, sessions.Config{
+		TokenSecret:   secret,
+		DeletionGrace: time.Hour,
+		CancelDeletion: func(ctx context.Context, userID uuid.UUID, deletedAt sql.NullTime) error {
+			cancelled = append(cancelled, userID)
+			return nil
+		},
+		Notifier: notifier,
+	})
+	store.addUser("walt@breakingbad.com", "", sql.NullTime{})
+	store.addUser("tuco@breakingbad.com", denylist.Banned, sql.NullTime{})
+	store.addUser("saul@breakingbad.com", denylist.Suspended, sql.NullTime{})
+	jesse := store.addUser("jesse@breakingbad.com", "", sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true})
+	store.addUser("gale@breakingbad.com", "", sql.NullTime{Time: time.Now().Add(-2 * time.Hour), Valid: true})
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		code     apierror.Code
+	}{
+		{"ok", "walt@breakingbad.com", "heisenberg1", ""},
+		{"wrong password", "walt@breakingbad.com", "heisenberg2", apierror.CodeInvalidCredentials},
+		{"unknown email", "hank@dea.gov", "heisenberg1", apierror.CodeInvalidCredentials},
+		{"missing password", "walt@breakingbad.com", "", apierror.CodeValidation},
+		{"banned", "tuco@breakingbad.com", "heisenberg1", "account_banned"},
+		{"suspended", "saul@breakingbad.com", "heisenberg1", ""},
+		{"deleted recently", "jesse@breakingbad.com", "heisenberg1", ""},
+		{"deleted for good", "gale@breakingbad.com", "heisenberg1", apierror.CodeInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := s.Login(context.Background(), tt.email, tt.password, sessions.Client{})
+			if apiCode(err) != tt.code || (tt.code == "" && err != nil) {
+				t.Fatalf("err = %v, want code %q", err, tt.code)
+			}
+			if tt.code != "" {
+				return
+			}
+			userID, err := auth.ValidateJWT(session.AccessToken, secret)
+			if err != nil || userID != session.User.ID {
+				t.Errorf("access token for %v (%v), want %v", userID, err, session.User.ID)
+			}
+			if session.RefreshToken == "" || store.issued[len(store.issued)-1].Token != session.RefreshToken {
+				t.Errorf("refresh token %q wasn't stored", session.RefreshToken)
+			}
+		})
+	}
+	if len(store.issued) != 3 || len(notifier.sent) != 3 {
+		t.Errorf("issued %d refresh tokens and sent %d notifications, want 3", len(store.issued), len(notifier.sent))
+	}
+	if len(cancelled) != 1 || cancelled[0] != jesse {
+		t.Errorf("cancelled deleting %v, want only %v", cancelled, jesse)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	store := newFakeStore(t)
+	s := sessions.New(store, sessions.Config{TokenSecret: secret})
+	userID := uuid.New()
+	valid := sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}
+	store.tokens["good"] = database.GetUserByRefreshTokenRow{ID: userID, ExpiresAt: valid}
+	store.tokens["expired"] = database.GetUserByRefreshTokenRow{ID: userID, ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}}
+	store.tokens["revoked"] = database.GetUserByRefreshTokenRow{ID: userID, ExpiresAt: valid, RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+	store.tokens["banned"] = database.GetUserByRefreshTokenRow{ID: userID, ExpiresAt: valid, Status: string(denylist.Banned)}
+
+	tests := []struct {
+		token string
+		code  apierror.Code
+	}{
+		{"good", ""},
+		{"unknown", apierror.CodeUnauthorized},
+		{"expired", apierror.CodeUnauthorized},
+		{"revoked", apierror.CodeUnauthorized},
+		{"banned", "account_banned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			token, err := s.Refresh(context.Background(), tt.token)
+			if apiCode(err) != tt.code || (tt.code == "" && err != nil) {
+				t.Fatalf("err = %v, want code %q", err, tt.code)
+			}
+			if tt.code != "" {
+				return
+			}
+			if id, err := auth.ValidateJWT(token, secret); err != nil || id != userID {
+				t.Errorf("access token for %v (%v), want %v", id, err, userID)
+			}
+		})
+	}
+
+	if err := s.Revoke(context.Background(), "good"); err != nil || len(store.revoked) != 1 {
+		t.Errorf("Revoke: err = %v, revoked %v", err, store.revoked)
+	}
+}
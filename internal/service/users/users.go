@@ -0,0 +1,118 @@
+// Package users signs users up and changes their email and password.
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/database/sqlite"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Service manages accounts. Problems with the request are *apierror.Errors;
+// any other error is an internal one.
+type Service interface {
+	// Create signs up a new user.
+	Create(ctx context.Context, email, password string) (database.CreateUserRow, error)
+	// Update replaces a user's email and password.
+	Update(ctx context.Context, userID uuid.UUID, email, password string) (database.UpdateUserRow, error)
+	// SetPassword replaces a user's password, keeping their email.
+	SetPassword(ctx context.Context, userID uuid.UUID, password string) error
+}
+
+// Store is the storage the service needs. *database.Queries is one.
+type Store interface {
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.CreateUserRow, error)
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.UpdateUserRow, error)
+	SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error
+}
+
+var _ Store = (*database.Queries)(nil)
+
+// Users is the Service backed by a Store.
+type Users struct {
+	store Store
+}
+
+func New(store Store) *Users {
+	return &Users{store: store}
+}
+
+// ValidateCredentials checks the email and password for a new account or an
+// account's new details.
+func ValidateCredentials(email, password string) error {
+	var v validate.Validator
+	v.Field("email", email, validate.Required, validate.Email)
+	validatePassword(&v, password)
+	return v.Err()
+}
+
+// ErrEmailTaken is returned when another account already has the email.
+var ErrEmailTaken = apierror.New(409, apierror.CodeConflict, "email is already in use")
+
+// emailConflict turns the store refusing a duplicate email into
+// ErrEmailTaken. Email is the only unique column Create and Update write
+// besides the generated ID.
+func emailConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" || sqlite.IsUniqueViolation(err) {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+func validatePassword(v *validate.Validator, password string) {
+	v.Field("password", password, validate.Required, validate.Password(validate.DefaultPasswordPolicy))
+}
+
+func (u *Users) Create(ctx context.Context, email, password string) (database.CreateUserRow, error) {
+	if err := ValidateCredentials(email, password); err != nil {
+		return database.CreateUserRow{}, err
+	}
+	hashedPassword, err := auth.HashPasswordContext(ctx, password)
+	if err != nil {
+		return database.CreateUserRow{}, err
+	}
+	row, err := u.store.CreateUser(ctx, database.CreateUserParams{
+		Email:          email,
+		HashedPassword: hashedPassword,
+	})
+	return row, emailConflict(err)
+}
+
+func (u *Users) Update(ctx context.Context, userID uuid.UUID, email, password string) (database.UpdateUserRow, error) {
+	if err := ValidateCredentials(email, password); err != nil {
+		return database.UpdateUserRow{}, err
+	}
+	hashedPassword, err := auth.HashPasswordContext(ctx, password)
+	if err != nil {
+		return database.UpdateUserRow{}, err
+	}
+	row, err := u.store.UpdateUser(ctx, database.UpdateUserParams{
+		Email:          email,
+		HashedPassword: hashedPassword,
+		UserID:         userID,
+	})
+	return row, emailConflict(err)
+}
+
+func (u *Users) SetPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	var v validate.Validator
+	validatePassword(&v, password)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	hashedPassword, err := auth.HashPasswordContext(ctx, password)
+	if err != nil {
+		return err
+	}
+	return u.store.SetUserPassword(ctx, database.SetUserPasswordParams{
+		HashedPassword: hashedPassword,
+		ID:             userID,
+	})
+}
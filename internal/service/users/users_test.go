@@ -0,0 +1,125 @@
+package users_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/service/users"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type fakeStore struct {
+	created   []database.CreateUserParams
+	updated   []database.UpdateUserParams
+	passwords []database.SetUserPasswordParams
+	err       error
+}
+
+func (s *fakeStore) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.CreateUserRow, error) {
+	if s.err != nil {
+		return database.CreateUserRow{}, s.err
+	}
+	s.created = append(s.created, arg)
+	return database.CreateUserRow{ID: uuid.New(), Email: arg.Email}, nil
+}
+
+func (s *fakeStore) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.UpdateUserRow, error) {
+	if s.err != nil {
+		return database.UpdateUserRow{}, s.err
+	}
+	s.updated = append(s.updated, arg)
+	return database.UpdateUserRow{ID: arg.UserID, Email: arg.Email}, nil
+}
+
+func (s *fakeStore) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	s.passwords = append(s.passwords, arg)
+	return nil
+}
+
+func TestCreateHashesThePassword(t *testing.T) {
+	store := &fakeStore{}
+	row, err := users.New(store).Create(context.Background(), "walt@breakingbad.com", "heisenberg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.Email != "walt@breakingbad.com" || len(store.created) != 1 {
+		t.Fatalf("got %+v, stored %+v", row, store.created)
+	}
+	hash := store.created[0].HashedPassword
+	if ok, err := auth.CheckPasswordHash("heisenberg1", hash); !ok || err != nil {
+		t.Errorf("stored %q, which doesn't match the password", hash)
+	}
+}
+
+func TestInvalidCredentialsAreNotStored(t *testing.T) {
+	store := &fakeStore{}
+	s := users.New(store)
+	ctx := context.Background()
+
+	_, err := s.Create(ctx, "walt", "short")
+	var e *apierror.Error
+	if !errors.As(err, &e) || e.Status != 422 || len(e.Fields) != 2 {
+		t.Errorf("Create: err = %v, want both fields invalid", err)
+	}
+	_, err = s.Update(ctx, uuid.New(), "walt@breakingbad.com", "")
+	if !errors.As(err, &e) || len(e.Fields) != 1 || e.Fields[0].Field != "password" {
+		t.Errorf("Update: err = %v, want the password invalid", err)
+	}
+	if len(store.created) > 0 || len(store.updated) > 0 {
+		t.Errorf("stored %+v and %+v", store.created, store.updated)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	store := &fakeStore{}
+	userID := uuid.New()
+	row, err := users.New(store).Update(context.Background(), userID, "skyler@breakingbad.com", "carwash123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.ID != userID || len(store.updated) != 1 || store.updated[0].HashedPassword == "carwash123" {
+		t.Errorf("got %+v, stored %+v", row, store.updated)
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	store := &fakeStore{}
+	s := users.New(store)
+	userID := uuid.New()
+	var e *apierror.Error
+	if err := s.SetPassword(context.Background(), userID, "a"); !errors.As(err, &e) || e.Status != 422 {
+		t.Errorf("SetPassword with a short password: err = %v, want 422", err)
+	}
+	if err := s.SetPassword(context.Background(), userID, "carwash123"); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.passwords) != 1 || store.passwords[0].ID != userID {
+		t.Fatalf("stored %+v", store.passwords)
+	}
+	if ok, err := auth.CheckPasswordHash("carwash123", store.passwords[0].HashedPassword); !ok || err != nil {
+		t.Errorf("stored %q, which doesn't match the password", store.passwords[0].HashedPassword)
+	}
+}
+
+func TestDuplicateEmail(t *testing.T) {
+	s := users.New(&fakeStore{err: &pq.Error{Code: "23505"}})
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, "walt@breakingbad.com", "heisenberg1"); !errors.Is(err, users.ErrEmailTaken) {
+		t.Errorf("Create: err = %v, want ErrEmailTaken", err)
+	}
+	if _, err := s.Update(ctx, uuid.New(), "walt@breakingbad.com", "heisenberg1"); !errors.Is(err, users.ErrEmailTaken) {
+		t.Errorf("Update: err = %v, want ErrEmailTaken", err)
+	}
+
+	other := errors.New("connection refused")
+	s = users.New(&fakeStore{err: other})
+	if _, err := s.Create(ctx, "walt@breakingbad.com", "heisenberg1"); err != other {
+		t.Errorf("Create: err = %v, want %v", err, other)
+	}
+}
@@ -0,0 +1,159 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/recorder"
+	"github.com/Blustak/bootdev-chirpy/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return spans
+}
+
+func TestMiddleware(t *testing.T) {
+	spans := setupRecorder(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	req := httptest.NewRequest("GET", "/api/chirps/123", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-00f067aa0ba902b7-01")
+
+	tracing.Middleware(recorder.Route(mux)).ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d spans, want 1", len(ended))
+	}
+	span := ended[0]
+	if span.Name() != "GET /api/chirps/{chirpID}" {
+		t.Errorf("span name = %q, want the route pattern", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != parentTraceID {
+		t.Errorf("trace id = %s, want it continued from traceparent %s", got, parentTraceID)
+	}
+	if span.Status().Code.String() != "Error" {
+		t.Errorf("span status = %v, want Error for a 500", span.Status().Code)
+	}
+}
+
+func TestTransportPropagates(t *testing.T) {
+	spans := setupRecorder(t)
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	req, err := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: tracing.Transport(nil)}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	parent.End()
+
+	want := parent.SpanContext().TraceID().String()
+	if !strings.Contains(traceparent, want) {
+		t.Errorf("traceparent = %q, want trace id %s", traceparent, want)
+	}
+	if len(spans.Ended()) != 2 {
+		t.Errorf("got %d spans, want the parent and the client span", len(spans.Ended()))
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "chirpy-test",
+		Exporter:    tracing.ExporterFile,
+		File:        path,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "exported")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exported struct{ Name string }
+	if err := json.NewDecoder(strings.NewReader(string(data))).Decode(&exported); err != nil {
+		t.Fatalf("exported span isn't JSON: %v", err)
+	}
+	if exported.Name != "exported" {
+		t.Errorf("exported span name = %q, want %q", exported.Name, "exported")
+	}
+}
+
+func TestStdoutExporterWritesToStderr(t *testing.T) {
+	// The logs are on standard output, so spans go to standard error.
+	dir := t.TempDir()
+	stdout, stderr := os.Stdout, os.Stderr
+	t.Cleanup(func() { os.Stdout, os.Stderr = stdout, stderr })
+	var err error
+	if os.Stdout, err = os.Create(filepath.Join(dir, "stdout")); err != nil {
+		t.Fatal(err)
+	}
+	if os.Stderr, err = os.Create(filepath.Join(dir, "stderr")); err != nil {
+		t.Fatal(err)
+	}
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "chirpy-test",
+		Exporter:    tracing.ExporterStdout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "exported")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{"stdout": false, "stderr": true} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(data), `"exported"`); got != want {
+			t.Errorf("span written to %s: %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestUnknownExporter(t *testing.T) {
+	if _, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "carrier-pigeon"}); err == nil {
+		t.Error("Setup accepted an unknown exporter")
+	}
+}
@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+)
+
+// DefaultMaxBodyBytes limits JSON request bodies unless a handler asks for
+// something else.
+const DefaultMaxBodyBytes = 64 << 10
+
+// DecodeJSON decodes the request body into dst. It rejects bodies over
+// maxBytes (413), fields dst doesn't have, values of the wrong type, empty
+// bodies and anything after the JSON value (all 400).
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any, maxBytes int64) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "request body must contain a single JSON value")
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return apierror.New(http.StatusBadRequest, apierror.CodeInvalidJSON, "request body is empty")
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return &apierror.Error{
+			Status: http.StatusBadRequest,
+			Code:   apierror.CodeInvalidJSON,
+			Detail: "request body has a value of the wrong type",
+			Fields: []apierror.FieldError{{
+				Field:   field,
+				Code:    "invalid_type",
+				Message: fmt.Sprintf("must be %s", jsonType(typeErr.Type.Kind().String())),
+			}},
+			Err: err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no type for this error.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &apierror.Error{
+			Status: http.StatusBadRequest,
+			Code:   apierror.CodeInvalidJSON,
+			Detail: "request body has an unknown field",
+			Fields: []apierror.FieldError{{Field: field, Code: "unknown_field", Message: "is not allowed"}},
+			Err:    err,
+		}
+	}
+	return apierror.InvalidJSON(err)
+}
+
+// jsonType names a Go kind the way a client writing JSON thinks of it.
+func jsonType(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "slice", "array":
+		return "an array"
+	case "map", "struct":
+		return "an object"
+	}
+	if strings.HasPrefix(kind, "int") || strings.HasPrefix(kind, "uint") || strings.HasPrefix(kind, "float") {
+		return "a number"
+	}
+	return "a " + kind
+}
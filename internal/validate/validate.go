@@ -0,0 +1,180 @@
+// Package validate checks request payloads against declarative rules and
+// decodes JSON bodies strictly. Problems are collected per field and
+// reported together, as an *apierror.Error with status 422.
+package validate
+
+import (
+	"net/mail"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/google/uuid"
+	"github.com/rivo/uniseg"
+)
+
+// Rule is a check on a string field. Code and Message describe the problem
+// when Valid returns false.
+type Rule struct {
+	Code    string
+	Message string
+	Valid   func(string) bool
+}
+
+// Required rejects empty and all-whitespace values.
+var Required = Rule{
+	Code:    "required",
+	Message: "is required",
+	Valid:   func(s string) bool { return strings.TrimSpace(s) != "" },
+}
+
+// Email accepts a bare address such as walt@breakingbad.com, without a
+// display name.
+var Email = Rule{
+	Code:    "invalid_email",
+	Message: "must be an email address",
+	Valid: func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s && strings.Contains(s[strings.LastIndex(s, "@"):], ".")
+	},
+}
+
+// UUID accepts a UUID in its canonical form.
+var UUID = Rule{
+	Code:    "invalid_uuid",
+	Message: "must be a UUID",
+	Valid: func(s string) bool {
+		_, err := uuid.Parse(s)
+		return err == nil
+	},
+}
+
+// Graphemes counts user-perceived characters: "👍🏽" and "é" written as e and
+// a combining accent are one each, however many bytes and runes they take.
+func Graphemes(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// MaxLength rejects values longer than n characters, counted as graphemes.
+func MaxLength(n int) Rule {
+	return Rule{
+		Code:    "too_long",
+		Message: "must be at most " + strconv.Itoa(n) + " characters",
+		Valid:   func(s string) bool { return Graphemes(s) <= n },
+	}
+}
+
+// OneOf accepts only the given values.
+func OneOf(values ...string) Rule {
+	return Rule{
+		Code:    "invalid_choice",
+		Message: "must be one of " + strings.Join(values, ", "),
+		Valid: func(s string) bool {
+			for _, v := range values {
+				if s == v {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// PasswordPolicy is what makes a password strong enough.
+type PasswordPolicy struct {
+	MinLength int
+	// MaxLength keeps hashing cheap; 0 means no limit.
+	MaxLength     int
+	RequireLetter bool
+	RequireDigit  bool
+}
+
+// DefaultPasswordPolicy is the policy for new passwords.
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:     8,
+	MaxLength:     128,
+	RequireLetter: true,
+	RequireDigit:  true,
+}
+
+// Password checks a password against p.
+func Password(p PasswordPolicy) Rule {
+	msg := "must be at least " + strconv.Itoa(p.MinLength) + " characters"
+	if p.MaxLength > 0 {
+		msg = "must be " + strconv.Itoa(p.MinLength) + " to " + strconv.Itoa(p.MaxLength) + " characters"
+	}
+	switch {
+	case p.RequireLetter && p.RequireDigit:
+		msg += " and contain a letter and a digit"
+	case p.RequireLetter:
+		msg += " and contain a letter"
+	case p.RequireDigit:
+		msg += " and contain a digit"
+	}
+	return Rule{
+		Code:    "weak_password",
+		Message: msg,
+		Valid: func(s string) bool {
+			n := Graphemes(s)
+			if n < p.MinLength || (p.MaxLength > 0 && n > p.MaxLength) {
+				return false
+			}
+			if p.RequireLetter && strings.IndexFunc(s, unicode.IsLetter) < 0 {
+				return false
+			}
+			if p.RequireDigit && strings.IndexFunc(s, unicode.IsDigit) < 0 {
+				return false
+			}
+			return true
+		},
+	}
+}
+
+// Validator collects the problems with a request.
+type Validator struct {
+	errs []apierror.FieldError
+}
+
+// Field checks value against rules in order, recording the first one it
+// breaks.
+func (v *Validator) Field(name, value string, rules ...Rule) {
+	for _, r := range rules {
+		if !r.Valid(value) {
+			v.Add(name, r.Code, r.Message)
+			return
+		}
+	}
+}
+
+// Check records a problem with field when ok is false, for checks that
+// aren't on a single string.
+func (v *Validator) Check(ok bool, field, code, message string) {
+	if !ok {
+		v.Add(field, code, message)
+	}
+}
+
+// Add records a problem with field.
+func (v *Validator) Add(field, code, message string) {
+	v.errs = append(v.errs, apierror.FieldError{Field: field, Code: code, Message: message})
+}
+
+// Valid reports whether no problems were recorded.
+func (v *Validator) Valid() bool {
+	return len(v.errs) == 0
+}
+
+// Err returns nil if the request is valid, or else an error with status 422
+// listing every problem.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return &apierror.Error{
+		Status: 422,
+		Code:   apierror.CodeValidation,
+		Detail: "request is invalid",
+		Fields: v.errs,
+	}
+}
@@ -0,0 +1,141 @@
+package validate_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  validate.Rule
+		value string
+		want  bool
+	}{
+		{"required", validate.Required, "x", true},
+		{"required blank", validate.Required, "  ", false},
+		{"email", validate.Email, "walt@breakingbad.com", true},
+		{"email with name", validate.Email, "Walt <walt@breakingbad.com>", false},
+		{"email without domain", validate.Email, "walt@", false},
+		{"email without dot", validate.Email, "walt@localhost", false},
+		{"uuid", validate.UUID, "4f9c1a6e-8f0c-4a3b-9f3e-2d1a5b7c9e01", true},
+		{"uuid invalid", validate.UUID, "4f9c1a6e", false},
+		{"one of", validate.OneOf("a", "b"), "b", true},
+		{"one of invalid", validate.OneOf("a", "b"), "c", false},
+		{"password", validate.Password(validate.DefaultPasswordPolicy), "hunter22", true},
+		{"password short", validate.Password(validate.DefaultPasswordPolicy), "hunt3r", false},
+		{"password no digit", validate.Password(validate.DefaultPasswordPolicy), "hunterhunter", false},
+		{"password no letter", validate.Password(validate.DefaultPasswordPolicy), "12345678", false},
+		{"password too long", validate.Password(validate.DefaultPasswordPolicy), strings.Repeat("a1", 65), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Valid(tt.value); got != tt.want {
+				t.Errorf("%s(%q) = %v, want %v", tt.rule.Code, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxLengthCountsGraphemes(t *testing.T) {
+	// Each is one character to a reader but several bytes.
+	for _, char := range []string{"é", "é", "👍🏽", "🇳🇿"} {
+		body := strings.Repeat(char, 140)
+		if !validate.MaxLength(140).Valid(body) {
+			t.Errorf("140 × %q rejected, %d bytes", char, len(body))
+		}
+		if validate.MaxLength(140).Valid(body + char) {
+			t.Errorf("141 × %q accepted", char)
+		}
+	}
+}
+
+func TestValidatorReportsEveryField(t *testing.T) {
+	var v validate.Validator
+	v.Field("email", "", validate.Required, validate.Email)
+	v.Field("password", "short", validate.Required, validate.Password(validate.DefaultPasswordPolicy))
+	v.Field("body", "fine", validate.Required)
+	err := v.Err()
+
+	var e *apierror.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %v, want an *apierror.Error", err)
+	}
+	if e.Status != 422 || e.Code != apierror.CodeValidation {
+		t.Errorf("status, code = %d, %s, want 422, %s", e.Status, e.Code, apierror.CodeValidation)
+	}
+	want := []apierror.FieldError{
+		{Field: "email", Code: "required", Message: "is required"},
+		{Field: "password", Code: "weak_password", Message: validate.Password(validate.DefaultPasswordPolicy).Message},
+	}
+	if len(e.Fields) != len(want) {
+		t.Fatalf("fields = %+v, want %+v", e.Fields, want)
+	}
+	for i := range want {
+		if e.Fields[i] != want[i] {
+			t.Errorf("fields[%d] = %+v, want %+v", i, e.Fields[i], want[i])
+		}
+	}
+}
+
+func TestValidatorValid(t *testing.T) {
+	var v validate.Validator
+	v.Field("email", "walt@breakingbad.com", validate.Required, validate.Email)
+	if err := v.Err(); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type request struct {
+		Email string `json:"email"`
+		Count int    `json:"count"`
+	}
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+		wantCode   string
+	}{
+		{name: "valid", body: `{"email": "walt@breakingbad.com", "count": 1}`},
+		{name: "empty", body: ``, wantStatus: 400},
+		{name: "malformed", body: `{"email":`, wantStatus: 400},
+		{name: "unknown field", body: `{"emial": "walt@breakingbad.com"}`, wantStatus: 400, wantField: "emial", wantCode: "unknown_field"},
+		{name: "wrong type", body: `{"count": "one"}`, wantStatus: 400, wantField: "count", wantCode: "invalid_type"},
+		{name: "trailing data", body: `{"count": 1} {"count": 2}`, wantStatus: 400},
+		{name: "too large", body: `{"email": "` + strings.Repeat("a", 100) + `"}`, wantStatus: 413},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			var dst request
+			err := validate.DecodeJSON(httptest.NewRecorder(), r, &dst, 64)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			var e *apierror.Error
+			if !errors.As(err, &e) {
+				t.Fatalf("err = %v, want an *apierror.Error", err)
+			}
+			if e.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", e.Status, tt.wantStatus)
+			}
+			if tt.wantField == "" {
+				return
+			}
+			if len(e.Fields) != 1 || e.Fields[0].Field != tt.wantField || e.Fields[0].Code != tt.wantCode {
+				t.Errorf("fields = %+v, want %s %s", e.Fields, tt.wantField, tt.wantCode)
+			}
+		})
+	}
+}
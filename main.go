@@ -1,20 +1,38 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"slices"
-	"strings"
+	"os/signal"
 	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
 	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/config"
 	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/denylist"
+	"github.com/Blustak/bootdev-chirpy/internal/health"
+	"github.com/Blustak/bootdev-chirpy/internal/logging"
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/pubsub"
+	"github.com/Blustak/bootdev-chirpy/internal/recorder"
+	"github.com/Blustak/bootdev-chirpy/internal/scheduler"
+	"github.com/Blustak/bootdev-chirpy/internal/service/chirps"
+	"github.com/Blustak/bootdev-chirpy/internal/service/sessions"
+	"github.com/Blustak/bootdev-chirpy/internal/service/users"
+	"github.com/Blustak/bootdev-chirpy/internal/tracing"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -25,13 +43,21 @@ type Chirp struct {
 }
 
 func (c Chirp) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]any{
+	res := map[string]any{
 		"id":         c.ID,
 		"created_at": c.CreatedAt,
 		"updated_at": c.UpdatedAt,
 		"body":       c.Body,
 		"user_id":    c.UserID,
-	})
+	}
+	if c.Status != "" && c.Status != chirps.StatusPublished {
+		res["status"] = c.Status
+		res["publish_at"] = nil
+		if c.PublishAt.Valid {
+			res["publish_at"] = c.PublishAt.Time
+		}
+	}
+	return json.Marshal(res)
 }
 
 
@@ -70,6 +96,14 @@ func (u getUserByEmailRow) User() User {
 	}
 }
 
+// sessionUser is a logged in user along with their tokens.
+func sessionUser(s sessions.Session) User {
+	user := getUserByEmailRow(s.User).User()
+	user.Token = s.AccessToken
+	user.RefreshToken = s.RefreshToken
+	return user
+}
+
 type User struct {
 	ID           uuid.UUID `json:"id"`
 	CreatedAt    time.Time `json:"created_at"`
@@ -92,17 +126,28 @@ const (
 )
 
 type apiConfig struct {
-	fileServerHits atomic.Int32
+	fileServerHits atomic.Uint64
 	platform       Platform
-	dbQueries      *database.Queries
+	storage        *storage
+	dbQueries      database.Store
 	tokenSecret    string
     polkaAPIKey string
-}
-
-var restricted_words = [...]string{
-	"sharbert",
-	"kerfuffle",
-	"fornax",
+	notifier       *notify.Notifier
+	bus            pubsub.Bus
+	// chirpUndoWindow is how long a deleted chirp can be restored, and
+	// accountDeletionGrace how long a deleted account can be recovered.
+	chirpUndoWindow      time.Duration
+	accountDeletionGrace time.Duration
+	// stopStreams is closed when the server shuts down, to end the chirp
+	// streams.
+	stopStreams <-chan struct{}
+	// denylist holds suspended and banned users, checked on every request
+	// carrying an access token.
+	denylist *denylist.Denylist
+	// The services hold the rules shared by every API; see initServices.
+	users    users.Service
+	chirps   chirps.Service
+	sessions sessions.Service
 }
 
 func (cfg *apiConfig) middlewareIncrementHits(next http.Handler) http.Handler {
@@ -112,143 +157,199 @@ func (cfg *apiConfig) middlewareIncrementHits(next http.Handler) http.Handler {
 	})
 }
 
-func lookupKeyOrPanic(envVariable string) string {
-    v,ok := os.LookupEnv(envVariable)
-    if !ok {
-        panic(fmt.Sprintf("Couldn't find environment variable %s", envVariable))
-    }
-    if v == "" {
-        panic(fmt.Sprintf("Empty environment variable %s", envVariable))
-    }
-    return v
+// queriesTx returns queries that run in tx, instrumented like cfg.dbQueries.
+func (cfg *apiConfig) queriesTx(tx *sql.Tx) database.Store {
+	return cfg.storage.queries(tx)
 }
 
 func main() {
 	godotenv.Load()
-	dbURL := os.Getenv("DB_URL")
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		panic("Couldn't connect to postgresql database.")
+	args := os.Args[1:]
+	var err error
+	switch {
+	case len(args) > 0 && args[0] == "config":
+		err = configCommand(args[1:])
+	case len(args) > 0 && args[0] == "migrate":
+		err = migrateCommand(args[1:])
+	case len(args) > 0 && args[0] == "admin":
+		err = adminCommand(args[1:])
+	default:
+		err = run(args)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
 	}
-	apiState := apiConfig{
-		fileServerHits: atomic.Int32{},
-		dbQueries:      database.New(db),
-		platform:       Platform(os.Getenv("PLATFORM")),
-		tokenSecret: func() string {
-            s := lookupKeyOrPanic("TOKEN_STRING")
-			if len(s) < 64 {
-				panic("bad secrets token")
-			}
-			return s
-		}(),
-        polkaAPIKey: lookupKeyOrPanic("POLKA_KEY"),
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chirpy:", err)
+		os.Exit(1)
 	}
-	serve := http.NewServeMux()
-
-	serve.HandleFunc("GET /api/healthz", readinessHandler)
-
-	serve.HandleFunc("POST /api/users", apiState.addUserHandler)
-	serve.HandleFunc("PUT /api/users", apiState.updateUserHandler)
-
-	serve.HandleFunc("POST /api/login", apiState.userLoginHandler)
-
-	serve.HandleFunc("POST /api/refresh", apiState.refreshTokenHandler)
-	serve.HandleFunc("POST /api/revoke", apiState.revokeHandler)
-
-	serve.HandleFunc("POST /api/chirps", apiState.chirpsHandler)
-	serve.HandleFunc("GET /api/chirps", apiState.getChirpsHandler)
-	serve.HandleFunc("GET /api/chirps/{chirpID}", apiState.getChirpByIdHandler)
-    serve.HandleFunc("DELETE /api/chirps/{chirpID}", apiState.deleteChirpByIDHandler)
+}
 
-    serve.HandleFunc("POST /api/polka/webhooks", apiState.polkaWebhooksHandler)
+// run starts the server, configured by args and the environment, and
+// serves until it's interrupted.
+func run(args []string) error {
+	conf, err := config.Load("chirpy", args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	logger := logging.New(os.Stdout, logging.ParseLevel(conf.LogLevel))
+	slog.SetDefault(logger)
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "chirpy",
+		Exporter:    conf.Tracing.Exporter,
+		File:        conf.Tracing.File,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+	http.DefaultClient.Transport = tracing.Transport(http.DefaultTransport)
+	store, err := openStorage(conf.DBURL)
+	if err != nil {
+		return err
+	}
+	db := store.db
+	defer db.Close()
+	configureDB(db, conf.Database)
+	if err := store.ping(context.Background()); err != nil {
+		return err
+	}
+	migrator, err := newMigrator(store)
+	if err != nil {
+		return err
+	}
+	if conf.Migrate {
+		results, err := migrator.Up(context.Background())
+		for _, r := range results {
+			slog.Info("applied migration", "version", r.Source.Version, "path", r.Source.Path, "duration", r.Duration.String())
+		}
+		if err != nil {
+			return fmt.Errorf("error migrating the database: %w", err)
+		}
+	}
+	dbQueries := store.queries(db)
+	notifier := notify.New(dbQueries, 1024)
+	notifier.Start(4)
+	defer notifier.Close()
+	bus := store.newBus(conf.DBURL)
+	defer bus.Close()
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	stopStreams := make(chan struct{})
+	apiState := apiConfig{
+		storage:        store,
+		dbQueries:      dbQueries,
+		platform:       Platform(conf.Platform),
+		tokenSecret:    conf.TokenSecret,
+		polkaAPIKey:    conf.PolkaAPIKey,
+		notifier:       notifier,
+		bus:            bus,
+		chirpUndoWindow:      conf.ChirpUndoWindow,
+		accountDeletionGrace: conf.AccountDeletionGrace,
+		stopStreams:          stopStreams,
+		denylist:             denylist.New(dbQueries, conf.AccountStatusTTL),
+	}
+	apiState.initServices()
+	metrics.RegisterFileServerHits(apiState.fileServerHits.Load)
+	publisher := scheduler.New(dbQueries, 5*time.Second, apiState.scheduledChirpPublished)
+	go publisher.Run(ctx)
+	purger := scheduler.NewPurger(dbQueries, time.Minute, apiState.chirpUndoWindow, apiState.accountDeletionGrace)
+	go purger.Run(ctx)
+
+	readiness := health.New(readinessTimeout)
+	readiness.Add("database", pingCheck(db))
+	readiness.Add("migrations", migrationsCheck(migrator))
+	readiness.Add("scheduler", workerCheck(publisher))
+	readiness.Add("purger", workerCheck(purger))
+	readiness.Add("notifier", func(context.Context) error { return notifier.Healthy() })
+	if pg, ok := bus.(*pubsub.PostgresBus); ok {
+		readiness.Add("pubsub", func(context.Context) error { return pg.Healthy() })
+	}
+	readiness.Add("server", func(context.Context) error {
+		select {
+		case <-stopStreams:
+			return errors.New("shutting down")
+		default:
+			return nil
+		}
+	})
 
-	serve.HandleFunc("GET /admin/metrics", apiState.hitsHandler)
-	serve.HandleFunc("POST /admin/reset", apiState.resetHandler)
+	serve := http.NewServeMux()
+	handleRoutes(serve, apiState.routes(readiness.Handler()))
 
-	fileServeHandle := http.StripPrefix(
-		"/app", http.FileServer(http.Dir(".")))
-	serve.Handle("/app/", apiState.middlewareIncrementHits(fileServeHandle))
-	serve.Handle("/assets", http.FileServer(http.Dir("./assets")))
+	server := newServer(conf.Server, tracing.Middleware(logging.Middleware(logger, metrics.Middleware(recorder.Route(apiState.middlewareAccountStatus(serve))))), stopStreams)
 
-	server := http.Server{
-		Handler: serve,
-		Addr:    ":8080",
+	// The gRPC server runs alongside, and stops when the HTTP server does.
+	grpcDone := make(chan error, 1)
+	if conf.Server.GRPCAddr == "" {
+		grpcDone <- nil
+	} else {
+		grpcServer, err := apiState.newGRPCServer(conf.Server)
+		if err != nil {
+			return fmt.Errorf("error setting up the grpc server: %w", err)
+		}
+		lis, err := net.Listen("tcp", conf.Server.GRPCAddr)
+		if err != nil {
+			return err
+		}
+		go func() {
+			err := runGRPCServer(ctx, conf.Server, grpcServer, lis)
+			cancel()
+			grpcDone <- err
+		}()
 	}
 
-	server.ListenAndServe()
-}
-
-func readinessHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(200)
-	w.Write([]byte("OK"))
-}
-
-func (cfg *apiConfig) hitsHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/html")
-	w.WriteHeader(200)
-	fmt.Fprintf(
-		w,
-		`<html>
-  <body>
-    <h1>Welcome, Chirpy Admin</h1>
-    <p>Chirpy has been visited %d times!</p>
-  </body>
-</html> `,
-		cfg.fileServerHits.Load(),
-	)
+	err = runServer(ctx, conf.Server, server)
+	cancel()
+	if grpcErr := <-grpcDone; err == nil {
+		err = grpcErr
+	}
+	return err
 }
 
 func (cfg *apiConfig) resetHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 	if cfg.platform != platformDev {
-		w.WriteHeader(403)
+		clientErrorResponse(w, 403, errors.New("reset is only available on the dev platform"))
+		return
+	}
+	cfg.fileServerHits.Store(0)
+	if err := cfg.dbQueries.ResetUserTable(r.Context()); err != nil {
+		serverErrorResponse(w, 500, err)
 		return
 	}
-	cfg.fileServerHits.Swap(int32(0))
-	cfg.dbQueries.ResetUserTable(r.Context())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(200)
 	w.Write([]byte("Reset\n"))
 }
 
 func (cfg *apiConfig) chirpsHandler(w http.ResponseWriter, r *http.Request) {
 
-	decoder := json.NewDecoder(r.Body)
 	var requestChirp struct {
-		ChirpBody string `json:"body"`
+		ChirpBody string     `json:"body"`
+		Draft     bool       `json:"draft"`
+		PublishAt *time.Time `json:"publish_at"`
 	}
-	if err := decoder.Decode(&requestChirp); err != nil {
+	if err := validate.DecodeJSON(w, r, &requestChirp, validate.DefaultMaxBodyBytes); err != nil {
 		clientErrorResponse(w, 400, err)
 		return
 	}
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		clientErrorResponse(w, 400, err)
-	}
-	id, err := auth.ValidateJWT(token, cfg.tokenSecret)
+	id, err := cfg.authenticate(r)
 	if err != nil {
 		clientErrorResponse(w, 401, err)
+		return
 	}
 
-	if len(requestChirp.ChirpBody) > 140 {
-		clientErrorResponse(w, 400, errors.New("chirp too long"))
+	requestChirp.ChirpBody, err = cfg.chirps.Prepare(r.Context(), id, requestChirp.ChirpBody)
+	if err != nil {
+		errorResponse(w, err)
 		return
 	}
-
-	bodyWords := strings.Split(string(requestChirp.ChirpBody), " ")
-	for i, w := range bodyWords {
-		for _, word := range restricted_words {
-			if strings.ToLower(w) == word {
-				bodyWords[i] = "****"
-			}
-		}
+	if requestChirp.Draft || requestChirp.PublishAt != nil {
+		cfg.saveUnpublishedChirp(w, r, id, requestChirp.ChirpBody, requestChirp.Draft, requestChirp.PublishAt)
+		return
 	}
-	requestChirp.ChirpBody = strings.Join(bodyWords, " ")
 	var res Chirp
-	res.Chirp, err = cfg.dbQueries.AddChirp(r.Context(), database.AddChirpParams{
-		ChirpBody: requestChirp.ChirpBody,
-		ID:        id,
-	})
+	res.Chirp, err = cfg.chirps.Add(r.Context(), id, requestChirp.ChirpBody)
 	if err != nil {
 		serverErrorResponse(w, 500, err)
 		return
@@ -265,204 +366,80 @@ func (cfg *apiConfig) chirpsHandler(w http.ResponseWriter, r *http.Request) {
 
 func (cfg *apiConfig) addUserHandler(w http.ResponseWriter, r *http.Request) {
 	reqStructure := userLoginRequest{}
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&reqStructure); err != nil {
+	if err := validate.DecodeJSON(w, r, &reqStructure, validate.DefaultMaxBodyBytes); err != nil {
 		clientErrorResponse(w, 400, err)
 		return
 	}
-	hashedPassword, err := auth.HashPassword(reqStructure.Password)
-	if err != nil {
-		log.Printf("error hashing password: %v\n", err)
-		serverErrorResponse(w, 400, err)
-		return
-	}
-	q, err := cfg.dbQueries.CreateUser(r.Context(),
-		database.CreateUserParams{
-			Email:          reqStructure.Email,
-			HashedPassword: hashedPassword,
-		})
+	row, err := cfg.users.Create(r.Context(), reqStructure.Email, reqStructure.Password)
 	if err != nil {
-		log.Printf("error adding user : %v\n", err)
-		serverErrorResponse(w, 500, err)
+		errorResponse(w, err)
 		return
 	}
-	user := createUserRow(q).User()
-	data, err := json.Marshal(user)
-	if err != nil {
-		log.Printf("error marshaling response : %v\n", err)
-		serverErrorResponse(w, 500, err)
-		return
-	}
-	w.WriteHeader(201)
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(data)
+	writeJSON(w, 201, createUserRow(row).User())
 }
 
 func (cfg *apiConfig) updateUserHandler(w http.ResponseWriter, r *http.Request) {
-	accessToken, err := auth.GetBearerToken(r.Header)
-	if err != nil || accessToken == "" {
-		clientErrorResponse(w, 401, err)
-		return
-	}
-	userID, err := auth.ValidateJWT(accessToken, cfg.tokenSecret)
+	userID, err := cfg.authenticate(r)
 	if err != nil {
 		clientErrorResponse(w, 401, err)
 		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	putData := struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}{}
-    if err = decoder.Decode(&putData); err != nil {
-        clientErrorResponse(w,401,err)
+	putData := userLoginRequest{}
+    if err = validate.DecodeJSON(w, r, &putData, validate.DefaultMaxBodyBytes); err != nil {
+        clientErrorResponse(w, 400, err)
         return
     }
-    hashedPass, err := auth.HashPassword(putData.Password)
+    userQuery, err := cfg.users.Update(r.Context(), userID, putData.Email, putData.Password)
     if err != nil {
-        serverErrorResponse(w,500,err)
-        return
-    }
-    userQuery, err := cfg.dbQueries.UpdateUser(
-        r.Context(),
-        database.UpdateUserParams{
-            Email: putData.Email,
-            HashedPassword: hashedPass,
-            UserID: userID,
-        },
-    )
-    if err != nil {
-        serverErrorResponse(w,500,err)
-        return
-    }
-    w.WriteHeader(200)
-    encoder := json.NewEncoder(w)
-    if err = encoder.Encode(
-        updateUserRow(userQuery).User(),
-    ); err != nil {
-        serverErrorResponse(w,500,err)
+        errorResponse(w, err)
         return
     }
+    writeJSON(w, 200, updateUserRow(userQuery).User())
 }
 
 func (cfg *apiConfig) userLoginHandler(w http.ResponseWriter, r *http.Request) {
 	var req userLoginRequest
-	var err error
-
-	decoder := json.NewDecoder(r.Body)
-	if err = decoder.Decode(&req); err != nil {
-		serverErrorResponse(w, 500, err)
-		return
-	}
-	row, err := cfg.dbQueries.GetUserByEmail(r.Context(), req.Email)
-	if err != nil || row == (database.GetUserByEmailRow{}) {
-		log.Printf("error getting user by email: %v", err)
-		w.WriteHeader(401)
-		w.Header().Add("Content-Type", "text/plain; charset=utf-8")
-		w.Write([]byte("incorrect email or password"))
-		return
-	}
-
-	hashedPass, err := cfg.dbQueries.GetHashedPasswordByID(r.Context(), row.ID)
-	if err != nil {
-		log.Printf("error getting hashed password:%v", err)
-		serverErrorResponse(w, 500, err)
-		return
-	}
-	ok, err := auth.CheckPasswordHash(req.Password, hashedPass)
-	if err != nil || !ok {
-		log.Printf("error checking hashed password:%v", err)
-		w.WriteHeader(401)
-		w.Header().Add("Content-Type", "text/plain; charset=utf-8")
-		w.Write([]byte("incorrect email or password"))
-		return
-	}
-	user := getUserByEmailRow(row).User()
-	user.Token, err = auth.MakeJWT(user.ID, cfg.tokenSecret, time.Hour*1)
-	if err != nil {
-		serverErrorResponse(w, 500, err)
-		return
-	}
-
-	user.RefreshToken, err = auth.MakeRefreshToken()
-	if err != nil {
-		serverErrorResponse(w, 500, err)
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
 		return
 	}
-	res, err := cfg.dbQueries.AddRefreshToken(r.Context(), database.AddRefreshTokenParams{
-		Token:  user.RefreshToken,
-		UserID: user.ID,
+	session, err := cfg.sessions.Login(r.Context(), req.Email, req.Password, sessions.Client{
+		UserAgent:  r.UserAgent(),
+		RemoteAddr: r.RemoteAddr,
 	})
 	if err != nil {
-		serverErrorResponse(w, 500, err)
+		errorResponse(w, err)
 		return
 	}
-	log.Printf("Added refresh token %v", res)
-	data, err := json.Marshal(user)
-	if err != nil {
-		log.Printf("error marshalling response: %v", err)
-		serverErrorResponse(w, 500, err)
-		return
-	}
-	w.WriteHeader(200)
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(data)
-
+	writeJSON(w, 200, sessionUser(session))
 }
 
 func (cfg *apiConfig) refreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		clientErrorResponse(w, 400, err)
-		return
-	}
-	refreshTokenQuery, err := cfg.dbQueries.GetUserByRefreshToken(r.Context(), token)
-	log.Printf("Got query: %v", refreshTokenQuery)
-	if err != nil || (refreshTokenQuery == database.GetUserByRefreshTokenRow{}) {
-		if errors.Is(err, sql.ErrNoRows) {
-			clientErrorResponse(w, 401, err)
-			return
-		}
-	}
-	if refreshTokenQuery.ExpiresAt.Valid {
-		if time.Now().After(refreshTokenQuery.ExpiresAt.Time) {
-			clientErrorResponse(w, 401, errors.New("refresh token has expired"))
-			return
-		}
-	} else {
-		panic("this should be unreachable; refresh token's expires at should never be null.")
-	}
-	if refreshTokenQuery.RevokedAt.Valid {
-		clientErrorResponse(w, 401, errors.New("token has been revoked"))
+		clientErrorResponse(w, 401, err)
 		return
 	}
-	accessToken, err := auth.MakeJWT(refreshTokenQuery.ID, cfg.tokenSecret, time.Hour*1)
+	accessToken, err := cfg.sessions.Refresh(r.Context(), token)
 	if err != nil {
-		serverErrorResponse(w, 500, errors.New("failed to create jwt token"))
+		errorResponse(w, err)
 		return
 	}
-	data, err := json.Marshal(&struct {
+	writeJSON(w, 200, struct {
 		Token string `json:"token"`
 	}{
 		Token: accessToken,
 	})
-	if err != nil {
-		serverErrorResponse(w, 500, err)
-		return
-	}
-	w.WriteHeader(200)
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(data)
 }
 
 func (cfg *apiConfig) revokeHandler(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil || token == "" {
-		clientErrorResponse(w, 400, errors.New("couldn't find refresh token"))
+		clientErrorResponse(w, 401, errors.New("couldn't find refresh token"))
 		return
 	}
-	if err := cfg.dbQueries.RevokeRefreshToken(r.Context(), token); err != nil {
-		clientErrorResponse(w, 401, err)
+	if err := cfg.sessions.Revoke(r.Context(), token); err != nil {
+		serverErrorResponse(w, 500, err)
 		return
 	}
 	w.WriteHeader(204)
@@ -472,7 +449,8 @@ func (cfg *apiConfig) revokeHandler(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) polkaWebhooksHandler(w http.ResponseWriter, r *http.Request) {
     apiKey, err := auth.GetApiKeyToken(r.Header)
     if err != nil || apiKey != cfg.polkaAPIKey {
-        clientErrorResponse(w,401,errors.New("unaothorized"))
+        metrics.Webhook("", metrics.WebhookUnauthorized)
+        clientErrorResponse(w, 401, errors.New("invalid API key"))
         return
     }
     var polkaWebhookEvent struct {
@@ -483,149 +461,142 @@ func (cfg *apiConfig) polkaWebhooksHandler(w http.ResponseWriter, r *http.Reques
     }
     decoder := json.NewDecoder(r.Body)
     if err := decoder.Decode(&polkaWebhookEvent); err != nil {
-        clientErrorResponse(w,400,err)
+        metrics.Webhook("", metrics.WebhookBadRequest)
+        clientErrorResponse(w, 400, apierror.InvalidJSON(err))
         return
     }
     if polkaWebhookEvent.Event != "user.upgraded" {
+        metrics.Webhook(polkaWebhookEvent.Event, metrics.WebhookIgnored)
         w.WriteHeader(204)
         return
     }
-    if err := cfg.dbQueries.UpgradeUserToChirpyRed(r.Context(),polkaWebhookEvent.Data.UserID); err != nil {
-        w.WriteHeader(404)
+    upgraded, err := cfg.dbQueries.UpgradeUserToChirpyRed(r.Context(),polkaWebhookEvent.Data.UserID)
+    if err != nil {
+        serverErrorResponse(w, 500, err)
+        return
+    }
+    if upgraded == 0 {
+        metrics.Webhook(polkaWebhookEvent.Event, metrics.WebhookNotFound)
+        clientErrorResponse(w, 404, errors.New("user not found"))
         return
     }
+	if err := cfg.notifier.Notify(notify.TypeChirpyRed, nil, polkaWebhookEvent.Data.UserID); err != nil {
+		slog.ErrorContext(r.Context(), "error queueing chirpy red notification", "error", err)
+	}
+	metrics.Webhook(polkaWebhookEvent.Event, metrics.WebhookUpgraded)
     w.WriteHeader(204)
 }
 
 func (cfg *apiConfig) getChirpsHandler(w http.ResponseWriter, r *http.Request) {
-    var chirps []Chirp
-    var query []database.Chirp
-    var err error
-
-    authorID := r.URL.Query().Get("author_id")
-    if authorID != "" {
-        authorUUID, err := uuid.Parse(authorID)
-        if err != nil {
-            clientErrorResponse(w,401,err)
-        }
-        query, err = cfg.dbQueries.GetChirpsFromUser(r.Context(),authorUUID)
-        if err != nil {
-            if errors.Is(err,sql.ErrNoRows) {
-                clientErrorResponse(w,404,err)
-                return
-            }
-            clientErrorResponse(w,401,err)
-            return
-        }
-    
-    } else {
-        query, err = cfg.dbQueries.GetAllChirps(r.Context())
-        if err != nil {
-            clientErrorResponse(w,404,err)
-            return
-        }
-    }
-    for _,q := range query {
-        chirps = append(chirps,Chirp{Chirp: q})
-    }
-    sortQuery := r.URL.Query().Get("sort")
-    if sortQuery == "desc" {
-        slices.SortFunc(chirps,func(a,b Chirp) int {
-            if a.CreatedAt.Before(b.CreatedAt) {
-                return 1
-            }
-            return -1
-        })
-    }
-    data,err := json.Marshal(chirps)
-    if err != nil {
-        serverErrorResponse(w,500,err)
-        return
-    }
-    w.WriteHeader(200)
-    w.Write(data)
+	viewer := cfg.optionalViewer(r)
+	var authorID uuid.NullUUID
+	if v := r.URL.Query().Get("author_id"); v != "" {
+		var err error
+		authorID.UUID, err = uuid.Parse(v)
+		if err != nil {
+			clientErrorResponse(w, 400, apierror.InvalidID("author_id", err))
+			return
+		}
+		authorID.Valid = true
+	}
+	query, err := cfg.chirps.List(r.Context(), viewer, authorID, r.URL.Query().Get("sort") == "desc")
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	chirps := make([]Chirp, len(query))
+	for i, q := range query {
+		chirps[i] = Chirp{Chirp: q}
+	}
+	writeJSON(w, 200, chirps)
 }
 
 
 func (cfg *apiConfig) getChirpByIdHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
-		clientErrorResponse(w, 404, err)
+		clientErrorResponse(w, 400, apierror.InvalidID("chirp ID", err))
 		return
 	}
+	viewer := cfg.optionalViewer(r)
 	var query Chirp
-	query.Chirp, err = cfg.dbQueries.GetChirpByID(r.Context(), id)
-	if err != nil {
-		clientErrorResponse(w, 404, err)
-		return
-	}
-	data, err := json.Marshal(query)
+	query.Chirp, err = cfg.dbQueries.GetChirpByID(r.Context(), database.GetChirpByIDParams{
+		ID:       id,
+		ViewerID: viewer,
+	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			clientErrorResponse(w, 404, chirps.ErrNotFound)
+			return
+		}
 		serverErrorResponse(w, 500, err)
 		return
 	}
-	w.WriteHeader(200)
-	w.Header().Add("Content-Type", "application/json")
-	w.Write(data)
-
+	writeJSON(w, 200, query)
 }
 
 func (cfg *apiConfig) deleteChirpByIDHandler(w http.ResponseWriter, r *http.Request) {
-    accessToken,err := auth.GetBearerToken(r.Header)
-    if err != nil || accessToken == "" {
-        clientErrorResponse(w, 401, errors.New("not authorized"))
-        return
-    }
-
-    userID, err := auth.ValidateJWT(accessToken,cfg.tokenSecret)
+    userID, err := cfg.authenticate(r)
     if err != nil {
-        clientErrorResponse(w,401, err)
+        clientErrorResponse(w, 401, err)
         return
     }
     chirpID, err := uuid.Parse(r.PathValue("chirpID"))
     if err != nil {
-        clientErrorResponse(w,404,err)
+        clientErrorResponse(w, 400, apierror.InvalidID("chirp ID", err))
         return
     }
-    chirpQuery, err := cfg.dbQueries.GetChirpByID(
-        r.Context(),
-        chirpID,
-    )
-    if err != nil {
-        clientErrorResponse(w, 404, err)
-        return
-    }
-    if chirpQuery.UserID != userID {
-        clientErrorResponse(w,403,errors.New("user mismatch"))
-        return
-    }
-    if err = cfg.dbQueries.DeleteChirpByID(r.Context(),chirpID); err != nil {
-        serverErrorResponse(w,500,err)
+    if err := cfg.chirps.Delete(r.Context(), userID, chirpID); err != nil {
+        errorResponse(w, err)
         return
     }
     w.WriteHeader(204)
 }
 
-func serverErrorResponse(w http.ResponseWriter, statusCode int, err error) {
-	log.Printf("server error: %v", err)
-	w.WriteHeader(statusCode)
-	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
-	fmt.Fprintf(w, "server error: %v", err)
+// optionalViewer authenticates the request when it carries a bearer token.
+// Anonymous requests get a null viewer, and so do requests whose token is
+// invalid or has expired: reads don't need one, so a stale token shouldn't
+// stop them.
+func (cfg *apiConfig) optionalViewer(r *http.Request) uuid.NullUUID {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
 }
 
-func clientErrorResponse(w http.ResponseWriter, statusCode int, err error) {
-	errPayload := struct {
-		Error string `json:"error"`
-	}{
-		Error: fmt.Sprintf("error: %v", err),
-	}
-	log.Printf("error: %v", err)
-	data, err := json.Marshal(errPayload)
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	data, err := json.Marshal(v)
 	if err != nil {
 		serverErrorResponse(w, 500, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	w.Header().Add("Content-Type", "application/json")
 	w.Write(data)
 }
+
+// serverErrorResponse logs err and reports an internal error without its
+// details.
+func serverErrorResponse(w http.ResponseWriter, statusCode int, err error) {
+	logging.SetError(w, err)
+	apierror.Write(w, &apierror.Error{Status: statusCode, Code: apierror.CodeInternal, Err: err})
+}
+
+// errorResponse reports err as a client error when it's an *apierror.Error,
+// and as an internal error otherwise.
+func errorResponse(w http.ResponseWriter, err error) {
+	var e *apierror.Error
+	if errors.As(err, &e) {
+		clientErrorResponse(w, e.Status, err)
+		return
+	}
+	serverErrorResponse(w, 500, err)
+}
+
+// clientErrorResponse reports err with statusCode, unless it's an
+// *apierror.Error carrying its own status.
+func clientErrorResponse(w http.ResponseWriter, statusCode int, err error) {
+	logging.SetError(w, err)
+	apierror.Write(w, apierror.Wrap(statusCode, err))
+}
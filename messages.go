@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/service/chirps"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+)
+
+const (
+	maxConversationMembers = 8
+	maxMessageLength       = 1000
+	defaultMessagePageSize = 50
+	maxMessagePageSize     = 200
+)
+
+type ConversationMember struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+}
+
+type Conversation struct {
+	ID          uuid.UUID            `json:"id"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	CreatedBy   uuid.UUID            `json:"created_by"`
+	Members     []ConversationMember `json:"members"`
+	UnreadCount int64                `json:"unread_count"`
+}
+
+type Message struct {
+	ID             uuid.UUID   `json:"id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	ConversationID uuid.UUID   `json:"conversation_id"`
+	SenderID       uuid.UUID   `json:"sender_id"`
+	Body           string      `json:"body"`
+	ReadBy         []uuid.UUID `json:"read_by"`
+}
+
+func conversationMembers(rows []database.ConversationMember) []ConversationMember {
+	members := make([]ConversationMember, 0, len(rows))
+	for _, row := range rows {
+		m := ConversationMember{
+			UserID:   row.UserID,
+			JoinedAt: row.JoinedAt,
+		}
+		if row.LastReadAt.Valid {
+			m.LastReadAt = &row.LastReadAt.Time
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+// messageFromRow builds a message with its read receipts: every member other
+// than the sender who has read the conversation past the message.
+func messageFromRow(m database.Message, members []database.ConversationMember) Message {
+	res := Message{
+		ID:             m.ID,
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+		ReadBy:         []uuid.UUID{},
+	}
+	for _, member := range members {
+		if member.UserID == m.SenderID || !member.LastReadAt.Valid {
+			continue
+		}
+		if !member.LastReadAt.Time.Before(m.CreatedAt) {
+			res.ReadBy = append(res.ReadBy, member.UserID)
+		}
+	}
+	return res
+}
+
+// conversationMember parses the conversation ID from the path and checks the
+// user belongs to it. Conversations the user isn't part of are reported as
+// missing.
+func (cfg *apiConfig) conversationMember(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (uuid.UUID, bool) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("conversation ID", err))
+		return uuid.UUID{}, false
+	}
+	ok, err := cfg.dbQueries.IsConversationMember(r.Context(), database.IsConversationMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return uuid.UUID{}, false
+	}
+	if !ok {
+		clientErrorResponse(w, 404, errors.New("conversation not found"))
+		return uuid.UUID{}, false
+	}
+	return conversationID, true
+}
+
+// checkCanMessage reports whether sender may message recipient, honouring the
+// recipient's DM allowlist and blocks.
+func (cfg *apiConfig) checkCanMessage(w http.ResponseWriter, r *http.Request, sender, recipient uuid.UUID) bool {
+	allowed, err := cfg.dbQueries.CanSendDirectMessage(r.Context(), database.CanSendDirectMessageParams{
+		SenderID:    sender,
+		RecipientID: recipient,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			clientErrorResponse(w, 404, fmt.Errorf("user %v not found", recipient))
+			return false
+		}
+		serverErrorResponse(w, 500, err)
+		return false
+	}
+	if !allowed {
+		clientErrorResponse(w, 403, fmt.Errorf("user %v doesn't accept messages from you", recipient))
+		return false
+	}
+	return true
+}
+
+func (cfg *apiConfig) writeConversation(w http.ResponseWriter, r *http.Request, statusCode int, c database.Conversation) {
+	members, err := cfg.dbQueries.GetConversationMembers(r.Context(), c.ID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	writeJSON(w, statusCode, Conversation{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		CreatedBy: c.CreatedBy,
+		Members:   conversationMembers(members),
+	})
+}
+
+func (cfg *apiConfig) createConversationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	var req struct {
+		MemberIDs []uuid.UUID `json:"member_ids"`
+	}
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	var recipients []uuid.UUID
+	for _, id := range req.MemberIDs {
+		if id != userID && !slices.Contains(recipients, id) {
+			recipients = append(recipients, id)
+		}
+	}
+	if len(recipients) == 0 {
+		clientErrorResponse(w, 400, errors.New("a conversation needs at least one other member"))
+		return
+	}
+	if len(recipients)+1 > maxConversationMembers {
+		clientErrorResponse(w, 400, fmt.Errorf("conversations are limited to %d members", maxConversationMembers))
+		return
+	}
+	for _, id := range recipients {
+		if !cfg.checkCanMessage(w, r, userID, id) {
+			return
+		}
+	}
+
+	direct := len(recipients) == 1
+	var pair database.GetDirectConversationParams
+	if direct {
+		pair = directPair(userID, recipients[0])
+		existing, err := cfg.dbQueries.GetDirectConversation(r.Context(), pair)
+		if err == nil {
+			cfg.writeConversation(w, r, 200, existing)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+	}
+
+	tx, err := cfg.storage.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queriesTx(tx)
+	conversation, err := qtx.CreateConversation(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	for _, id := range append([]uuid.UUID{userID}, recipients...) {
+		if err := qtx.AddConversationMember(r.Context(), database.AddConversationMemberParams{
+			ConversationID: conversation.ID,
+			UserID:         id,
+		}); err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+	}
+	if direct {
+		added, err := qtx.AddDirectConversation(r.Context(), database.AddDirectConversationParams{
+			UserA:          pair.UserA,
+			UserB:          pair.UserB,
+			ConversationID: conversation.ID,
+		})
+		if err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		if added == 0 {
+			// A concurrent request created the conversation between our
+			// lookup and the insert; drop ours and return theirs.
+			tx.Rollback()
+			existing, err := cfg.dbQueries.GetDirectConversation(r.Context(), pair)
+			if err != nil {
+				serverErrorResponse(w, 500, err)
+				return
+			}
+			cfg.writeConversation(w, r, 200, existing)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	cfg.writeConversation(w, r, 201, conversation)
+}
+
+// directPair orders two user IDs so each pair has a single
+// direct_conversations key, whoever starts the conversation.
+func directPair(a, b uuid.UUID) database.GetDirectConversationParams {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return database.GetDirectConversationParams{UserA: a, UserB: b}
+}
+
+func (cfg *apiConfig) getConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	rows, err := cfg.dbQueries.GetConversationsForUser(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	conversations := make([]Conversation, 0, len(rows))
+	for _, row := range rows {
+		members, err := cfg.dbQueries.GetConversationMembers(r.Context(), row.ID)
+		if err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+		conversations = append(conversations, Conversation{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			CreatedBy:   row.CreatedBy,
+			Members:     conversationMembers(members),
+			UnreadCount: row.UnreadCount,
+		})
+	}
+	writeJSON(w, 200, conversations)
+}
+
+func (cfg *apiConfig) sendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	conversationID, ok := cfg.conversationMember(w, r, userID)
+	if !ok {
+		return
+	}
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	var v validate.Validator
+	v.Field("body", req.Body, validate.Required, validate.MaxLength(maxMessageLength))
+	if err := v.Err(); err != nil {
+		clientErrorResponse(w, 422, err)
+		return
+	}
+	members, err := cfg.dbQueries.GetConversationMembers(r.Context(), conversationID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	// The allowlist is checked when someone is added to a group, but a one
+	// to one conversation follows the recipient's current setting.
+	if len(members) == 2 {
+		for _, m := range members {
+			if m.UserID != userID && !cfg.checkCanMessage(w, r, userID, m.UserID) {
+				return
+			}
+		}
+	}
+
+	tx, err := cfg.storage.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.queriesTx(tx)
+	message, err := qtx.AddMessage(r.Context(), database.AddMessageParams{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Body:           chirps.Censor(req.Body),
+	})
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if err := qtx.TouchConversation(r.Context(), conversationID); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if err := qtx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	writeJSON(w, 201, messageFromRow(message, nil))
+}
+
+func (cfg *apiConfig) getMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	conversationID, ok := cfg.conversationMember(w, r, userID)
+	if !ok {
+		return
+	}
+	limit, offset, err := pageQuery(r, defaultMessagePageSize, maxMessagePageSize)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	rows, err := cfg.dbQueries.GetMessages(r.Context(), database.GetMessagesParams{
+		ConversationID: conversationID,
+		PageSize:       int32(limit),
+		PageOffset:     int32(offset),
+	})
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	members, err := cfg.dbQueries.GetConversationMembers(r.Context(), conversationID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	messages := make([]Message, 0, len(rows))
+	for _, row := range rows {
+		messages = append(messages, messageFromRow(row, members))
+	}
+	writeJSON(w, 200, struct {
+		Messages []Message            `json:"messages"`
+		Members  []ConversationMember `json:"members"`
+		Limit    int                  `json:"limit"`
+		Offset   int                  `json:"offset"`
+	}{
+		Messages: messages,
+		Members:  conversationMembers(members),
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
+
+func (cfg *apiConfig) readConversationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	conversationID, ok := cfg.conversationMember(w, r, userID)
+	if !ok {
+		return
+	}
+	if err := cfg.dbQueries.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) writeDMAllowlist(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	allowlistOnly, err := cfg.dbQueries.GetDMAllowlistOnly(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	rows, err := cfg.dbQueries.GetDMAllowlist(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	userIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		userIDs = append(userIDs, row.AllowedUserID)
+	}
+	writeJSON(w, 200, struct {
+		AllowlistOnly bool        `json:"allowlist_only"`
+		UserIDs       []uuid.UUID `json:"user_ids"`
+	}{
+		AllowlistOnly: allowlistOnly,
+		UserIDs:       userIDs,
+	})
+}
+
+func (cfg *apiConfig) getDMAllowlistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	cfg.writeDMAllowlist(w, r, userID)
+}
+
+func (cfg *apiConfig) updateDMAllowlistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	var req struct {
+		AllowlistOnly *bool       `json:"allowlist_only"`
+		Add           []uuid.UUID `json:"add"`
+	}
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	if req.AllowlistOnly != nil {
+		if err := cfg.dbQueries.SetDMAllowlistOnly(r.Context(), database.SetDMAllowlistOnlyParams{
+			AllowlistOnly: *req.AllowlistOnly,
+			UserID:        userID,
+		}); err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+	}
+	for _, id := range req.Add {
+		if err := cfg.dbQueries.AddToDMAllowlist(r.Context(), database.AddToDMAllowlistParams{
+			UserID:        userID,
+			AllowedUserID: id,
+		}); err != nil {
+			if isForeignKeyViolation(err) {
+				clientErrorResponse(w, 404, fmt.Errorf("couldn't add user %v to allowlist", id))
+				return
+			}
+			serverErrorResponse(w, 500, err)
+			return
+		}
+	}
+	cfg.writeDMAllowlist(w, r, userID)
+}
+
+func (cfg *apiConfig) removeFromDMAllowlistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	allowedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("user ID", err))
+		return
+	}
+	if err := cfg.dbQueries.RemoveFromDMAllowlist(r.Context(), database.RemoveFromDMAllowlistParams{
+		UserID:        userID,
+		AllowedUserID: allowedID,
+	}); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	w.WriteHeader(204)
+}
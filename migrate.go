@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+	"github.com/Blustak/bootdev-chirpy/internal/health"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+const migrateUsage = "usage: chirpy migrate up|down|status|redo [flags]"
+
+// newMigrator returns a goose provider for the storage's embedded
+// migrations. On Postgres every change it makes holds an advisory lock, so
+// replicas started together with -migrate apply the migrations once, one
+// after the other.
+func newMigrator(s *storage) (*goose.Provider, error) {
+	if s.dialect != goose.DialectPostgres {
+		return goose.NewProvider(s.dialect, s.db, s.migrations)
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(s.dialect, s.db, s.migrations, goose.WithSessionLocker(locker))
+}
+
+// migrationsCheck fails unless every embedded migration has been applied,
+// and none that this build doesn't know about.
+func migrationsCheck(migrator *goose.Provider) health.Check {
+	return func(ctx context.Context) error {
+		current, target, err := migrator.GetVersions(ctx)
+		if err != nil {
+			return err
+		}
+		if current != target {
+			return fmt.Errorf("database is at version %d, want %d", current, target)
+		}
+		return nil
+	}
+}
+
+// migrateCommand implements "chirpy migrate".
+func migrateCommand(args []string) error {
+	if len(args) == 0 || !slices.Contains([]string{"up", "down", "status", "redo"}, args[0]) {
+		return errors.New(migrateUsage)
+	}
+	action := args[0]
+	conf, rest, err := config.LoadDatabase("chirpy migrate "+action, args[1:], os.Getenv)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return errors.New(migrateUsage)
+	}
+	store, err := openStorage(conf.DBURL)
+	if err != nil {
+		return err
+	}
+	defer store.db.Close()
+	migrator, err := newMigrator(store)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		results, err := migrator.Up(ctx)
+		printMigrationResults(results...)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		result, err := migrator.Down(ctx)
+		if result != nil {
+			printMigrationResults(result)
+		}
+		return err
+	case "redo":
+		down, err := migrator.Down(ctx)
+		if down != nil {
+			printMigrationResults(down)
+		}
+		if err != nil {
+			return err
+		}
+		up, err := migrator.UpByOne(ctx)
+		if up != nil {
+			printMigrationResults(up)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tMIGRATION\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "-"
+			if !s.AppliedAt.IsZero() {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.Source.Path, s.State, appliedAt)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+func printMigrationResults(results ...*goose.MigrationResult) {
+	for _, r := range results {
+		fmt.Println(r)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/auth"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/logging"
+	"github.com/Blustak/bootdev-chirpy/internal/notify"
+	"github.com/Blustak/bootdev-chirpy/internal/validate"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+type Notification struct {
+	ID        uuid.UUID       `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	Read      bool            `json:"read"`
+	ReadAt    *time.Time      `json:"read_at"`
+}
+
+func notificationFromRow(n database.Notification) Notification {
+	res := Notification{
+		ID:        n.ID,
+		CreatedAt: n.CreatedAt,
+		Type:      n.Type,
+		Payload:   n.Payload,
+		Read:      n.ReadAt.Valid,
+	}
+	if n.ReadAt.Valid {
+		res.ReadAt = &n.ReadAt.Time
+	}
+	return res
+}
+
+// authenticate returns the user ID from the request's bearer access token.
+func (cfg *apiConfig) authenticate(r *http.Request) (uuid.UUID, error) {
+	accessToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if accessToken == "" {
+		return uuid.UUID{}, errors.New("not authorized")
+	}
+	userID, err := auth.ValidateJWT(accessToken, cfg.tokenSecret)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	logging.SetUserID(r.Context(), userID)
+	return userID, nil
+}
+
+func pageQuery(r *http.Request, defaultSize, maxSize int) (limit, offset int, err error) {
+	limit, offset = defaultSize, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("invalid limit: %s", v)
+		}
+		limit = min(limit, maxSize)
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %s", v)
+		}
+	}
+	return limit, offset, nil
+}
+
+func (cfg *apiConfig) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	limit, offset, err := pageQuery(r, defaultNotificationPageSize, maxNotificationPageSize)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	rows, err := cfg.dbQueries.GetNotificationsForUser(r.Context(), database.GetNotificationsForUserParams{
+		UserID:     userID,
+		PageSize:   int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	unread, err := cfg.dbQueries.CountUnreadNotifications(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	notifications := make([]Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, notificationFromRow(row))
+	}
+	writeJSON(w, 200, struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+		Limit         int            `json:"limit"`
+		Offset        int            `json:"offset"`
+	}{
+		Notifications: notifications,
+		UnreadCount:   unread,
+		Limit:         limit,
+		Offset:        offset,
+	})
+}
+
+func (cfg *apiConfig) readNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	var req struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	switch {
+	case req.All:
+		err = cfg.dbQueries.MarkAllNotificationsRead(r.Context(), userID)
+	case len(req.IDs) > 0:
+		err = cfg.dbQueries.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    req.IDs,
+		})
+	default:
+		clientErrorResponse(w, 400, errors.New("either ids or all must be set"))
+		return
+	}
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	w.WriteHeader(204)
+}
+
+func (cfg *apiConfig) writeNotificationPreferences(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	rows, err := cfg.dbQueries.GetNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	prefs := make(map[notify.Type]bool, len(notify.Types))
+	for _, t := range notify.Types {
+		prefs[t] = true
+	}
+	for _, row := range rows {
+		prefs[notify.Type(row.Type)] = row.Enabled
+	}
+	writeJSON(w, 200, prefs)
+}
+
+func (cfg *apiConfig) getNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	cfg.writeNotificationPreferences(w, r, userID)
+}
+
+func (cfg *apiConfig) updateNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	var req map[notify.Type]bool
+	if err := validate.DecodeJSON(w, r, &req, validate.DefaultMaxBodyBytes); err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	var v validate.Validator
+	for t := range req {
+		v.Check(notify.ValidType(t), string(t), "unknown_type", "is not a notification type")
+	}
+	if err := v.Err(); err != nil {
+		clientErrorResponse(w, 422, err)
+		return
+	}
+	for t, enabled := range req {
+		if err := cfg.dbQueries.SetNotificationPreference(r.Context(), database.SetNotificationPreferenceParams{
+			UserID:  userID,
+			Type:    string(t),
+			Enabled: enabled,
+		}); err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+	}
+	cfg.writeNotificationPreferences(w, r, userID)
+}
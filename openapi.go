@@ -0,0 +1,29 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI 3.1 description of every route. The tests
+// check it against routes.
+//
+//go:embed docs/openapi.json
+var openAPISpec []byte
+
+// apiDocsPage renders openAPISpec in the browser. It's self-contained, so
+// the docs work without reaching a CDN.
+//
+//go:embed docs/api.html
+var apiDocsPage []byte
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(openAPISpec)
+}
+
+func apiDocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(apiDocsPage)
+}
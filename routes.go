@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+)
+
+// apiPrefix is where the current version of the API is served. The API is
+// also served under /api, without the version, for clients written before
+// it was versioned.
+const apiPrefix = "/api/v1"
+
+// route is a ServeMux pattern and the handler registered for it.
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+func handle(pattern string, handler http.HandlerFunc) route {
+	return route{pattern, handler}
+}
+
+// routes lists every endpoint the server has. handleRoutes serves the ones
+// under /api under apiPrefix too. docs/openapi.json must describe every one
+// of them, by its versioned path.
+func (cfg *apiConfig) routes(readiness http.Handler) []route {
+	gql := cfg.graphqlHandler()
+	return []route{
+		handle("GET /api/healthz", livenessHandler),
+		handle("GET /api/healthz/live", livenessHandler),
+		{"GET /api/healthz/ready", readiness},
+
+		handle("POST /api/users", cfg.addUserHandler),
+		handle("PUT /api/users", cfg.updateUserHandler),
+		handle("DELETE /api/users/me", cfg.deleteAccountHandler),
+		handle("GET /api/users/me/export", cfg.exportAccountHandler),
+
+		handle("POST /api/login", cfg.userLoginHandler),
+
+		handle("POST /api/refresh", cfg.refreshTokenHandler),
+		handle("POST /api/revoke", cfg.revokeHandler),
+
+		handle("POST /api/chirps", cfg.chirpsHandler),
+		handle("GET /api/chirps", cfg.getChirpsHandler),
+		handle("GET /api/chirps/{chirpID}", cfg.getChirpByIdHandler),
+		handle("GET /api/chirps/scheduled", cfg.getScheduledChirpsHandler),
+		handle("DELETE /api/chirps/scheduled/{chirpID}", cfg.deleteScheduledChirpHandler),
+		handle("POST /api/chirps/scheduled/{chirpID}/publish", cfg.publishScheduledChirpHandler),
+		handle("GET /api/stream", cfg.streamHandler),
+		handle("DELETE /api/chirps/{chirpID}", cfg.deleteChirpByIDHandler),
+		handle("POST /api/chirps/{chirpID}/restore", cfg.restoreChirpHandler),
+
+		{"GET /api/graphql", gql},
+		{"POST /api/graphql", gql},
+
+		handle("POST /api/polka/webhooks", cfg.polkaWebhooksHandler),
+
+		handle("POST /api/conversations", cfg.createConversationHandler),
+		handle("GET /api/conversations", cfg.getConversationsHandler),
+		handle("GET /api/conversations/{conversationID}/messages", cfg.getMessagesHandler),
+		handle("POST /api/conversations/{conversationID}/messages", cfg.sendMessageHandler),
+		handle("POST /api/conversations/{conversationID}/read", cfg.readConversationHandler),
+
+		handle("POST /api/users/{userID}/block", cfg.blockUserHandler),
+		handle("DELETE /api/users/{userID}/block", cfg.unblockUserHandler),
+		handle("POST /api/users/{userID}/mute", cfg.muteUserHandler),
+		handle("DELETE /api/users/{userID}/mute", cfg.unmuteUserHandler),
+
+		handle("GET /api/users/me/dm_allowlist", cfg.getDMAllowlistHandler),
+		handle("PUT /api/users/me/dm_allowlist", cfg.updateDMAllowlistHandler),
+		handle("DELETE /api/users/me/dm_allowlist/{userID}", cfg.removeFromDMAllowlistHandler),
+
+		handle("GET /api/notifications", cfg.getNotificationsHandler),
+		handle("POST /api/notifications/read", cfg.readNotificationsHandler),
+		handle("GET /api/notifications/preferences", cfg.getNotificationPreferencesHandler),
+		handle("PUT /api/notifications/preferences", cfg.updateNotificationPreferencesHandler),
+
+		handle("GET /api/openapi.json", openAPIHandler),
+		handle("GET /api/docs", apiDocsHandler),
+
+		{"GET /metrics", metrics.Handler()},
+		handle("GET /admin/metrics", cfg.hitsHandler),
+		handle("GET /admin/dashboard", cfg.requireAdmin(cfg.dashboardHandler)),
+		handle("GET /admin/api/users", cfg.requireAdmin(cfg.adminSearchUsersHandler)),
+		handle("GET /admin/api/users/{userID}", cfg.requireAdmin(cfg.adminGetUserHandler)),
+		handle("POST /admin/api/users/{userID}/suspend", cfg.requireAdmin(cfg.adminSuspendUserHandler)),
+		handle("DELETE /admin/api/users/{userID}/suspend", cfg.requireAdmin(cfg.adminReinstateUserHandler)),
+		handle("POST /admin/api/users/{userID}/ban", cfg.requireAdmin(cfg.adminBanUserHandler)),
+		handle("DELETE /admin/api/users/{userID}/ban", cfg.requireAdmin(cfg.adminReinstateUserHandler)),
+		handle("GET /admin/api/stats", cfg.requireAdmin(cfg.adminStatsHandler)),
+		handle("POST /admin/reset", cfg.resetHandler),
+
+		{"/app/", cfg.middlewareIncrementHits(http.StripPrefix("/app", http.FileServer(http.Dir("."))))},
+		{"/assets", http.FileServer(http.Dir("./assets"))},
+	}
+}
+
+// versioned returns the pattern of the versioned alias of an /api route,
+// or false for routes outside the API and those that describe it.
+func versioned(pattern string) (string, bool) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || !strings.HasPrefix(path, "/api/") || path == "/api/openapi.json" || path == "/api/docs" {
+		return "", false
+	}
+	return method + " " + apiPrefix + strings.TrimPrefix(path, "/api"), true
+}
+
+// handleRoutes registers routes on mux, with their versioned aliases.
+func handleRoutes(mux *http.ServeMux, routes []route) {
+	for _, rt := range routes {
+		mux.Handle(rt.pattern, rt.handler)
+		if v, ok := versioned(rt.pattern); ok {
+			mux.Handle(v, rt.handler)
+		}
+	}
+}
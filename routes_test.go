@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type openAPIDocument struct {
+	OpenAPI string                                `json:"openapi"`
+	Paths   map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+// specOperation is where the spec describes the route registered with
+// pattern: its method, lower case, and the versioned path. Patterns without
+// a method are file servers, described by their GET; a subtree pattern is
+// described as a path parameter.
+func specOperation(pattern string) (method, path string) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = http.MethodGet, pattern
+	}
+	if v, ok := versioned(pattern); ok {
+		_, path, _ = strings.Cut(v, " ")
+	}
+	if strings.HasSuffix(path, "/") {
+		path += "{path}"
+	}
+	return strings.ToLower(method), path
+}
+
+func loadSpec(t *testing.T) openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("docs/openapi.json: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	doc := loadSpec(t)
+	if !strings.HasPrefix(doc.OpenAPI, "3.1.") {
+		t.Errorf("openapi = %q, want 3.1", doc.OpenAPI)
+	}
+	var cfg apiConfig
+	registered := make(map[string]bool)
+	for _, rt := range cfg.routes(http.NotFoundHandler()) {
+		method, path := specOperation(rt.pattern)
+		registered[method+" "+path] = true
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("%s is registered but docs/openapi.json has no %s %s", rt.pattern, strings.ToUpper(method), path)
+		}
+	}
+	for path, item := range doc.Paths {
+		for method := range item {
+			if !registered[method+" "+path] {
+				t.Errorf("docs/openapi.json describes %s %s, which isn't registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatal(err)
+	}
+	operationIDs := make(map[string]bool)
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				var target any = doc
+				for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]any)
+					target = m[key]
+				}
+				if target == nil {
+					t.Errorf("$ref %s doesn't resolve", ref)
+				}
+			}
+			if id, ok := v["operationId"].(string); ok {
+				if operationIDs[id] {
+					t.Errorf("operationId %s is used twice", id)
+				}
+				operationIDs[id] = true
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
+
+func TestVersionedAliases(t *testing.T) {
+	mux := http.NewServeMux()
+	var cfg apiConfig
+	handleRoutes(mux, cfg.routes(http.NotFoundHandler()))
+	for _, path := range []string{"/api/healthz", "/api/v1/healthz", "/api/openapi.json", "/api/docs"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, w.Code)
+		}
+	}
+	for _, path := range []string{"/api/v1/openapi.json", "/api/v1/docs"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, w.Code)
+		}
+	}
+}
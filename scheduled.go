@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/apierror"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+	"github.com/Blustak/bootdev-chirpy/internal/service/chirps"
+	"github.com/google/uuid"
+)
+
+// saveUnpublishedChirp stores a draft, or a chirp to be published by the
+// scheduler at publishAt. Neither is visible to anyone but its author until
+// it is published.
+func (cfg *apiConfig) saveUnpublishedChirp(w http.ResponseWriter, r *http.Request, userID uuid.UUID, body string, draft bool, publishAt *time.Time) {
+	params := database.AddUnpublishedChirpParams{
+		ChirpBody: body,
+		UserID:    userID,
+		Status:    chirps.StatusDraft,
+	}
+	if !draft {
+		if !publishAt.After(time.Now()) {
+			clientErrorResponse(w, 400, errors.New("publish_at must be in the future"))
+			return
+		}
+		params.Status = chirps.StatusScheduled
+		params.PublishAt = sql.NullTime{Time: publishAt.UTC(), Valid: true}
+	}
+	chirp, err := cfg.dbQueries.AddUnpublishedChirp(r.Context(), params)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	metrics.ChirpCreated(params.Status)
+	writeJSON(w, 201, Chirp{Chirp: chirp})
+}
+
+func (cfg *apiConfig) getScheduledChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	rows, err := cfg.dbQueries.GetUnpublishedChirpsForUser(r.Context(), userID)
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	chirps := make([]Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, Chirp{Chirp: row})
+	}
+	writeJSON(w, 200, chirps)
+}
+
+func (cfg *apiConfig) deleteScheduledChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("chirp ID", err))
+		return
+	}
+	n, err := cfg.dbQueries.DeleteUnpublishedChirp(r.Context(), database.DeleteUnpublishedChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	if n == 0 {
+		clientErrorResponse(w, 404, errors.New("no draft or scheduled chirp with that id"))
+		return
+	}
+	w.WriteHeader(204)
+}
+
+// scheduledChirpPublished announces a chirp the scheduler has published, and
+// counts it as published like one published by hand.
+func (cfg *apiConfig) scheduledChirpPublished(ctx context.Context, c database.Chirp) {
+	metrics.ChirpCreated(chirps.StatusPublished)
+	cfg.publishChirp(ctx, c)
+}
+
+// publishScheduledChirpHandler publishes a draft or scheduled chirp right away.
+func (cfg *apiConfig) publishScheduledChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		clientErrorResponse(w, 400, apierror.InvalidID("chirp ID", err))
+		return
+	}
+	chirp, err := cfg.chirps.Publish(r.Context(), userID, chirpID)
+	if err != nil {
+		errorResponse(w, err)
+		return
+	}
+	writeJSON(w, 200, Chirp{Chirp: chirp})
+}
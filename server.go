@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func configureDB(db *sql.DB, c config.Database) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+// newServer sets up the HTTP server as configured. stopStreams is closed
+// when it shuts down: Shutdown doesn't wait for hijacked connections and
+// would wait out its deadline for event streams, so they're told to end
+// themselves.
+func newServer(c config.Server, handler http.Handler, stopStreams chan<- struct{}) *http.Server {
+	server := &http.Server{
+		Addr:              c.Addr,
+		Handler:           http.MaxBytesHandler(handler, c.MaxBodyBytes),
+		ReadHeaderTimeout: c.ReadHeaderTimeout,
+		ReadTimeout:       c.ReadTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+		MaxHeaderBytes:    c.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	if c.TLSCertFile == "" && len(c.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(c.AutocertDomains...),
+			Cache:      autocert.DirCache(c.AutocertCacheDir),
+			Email:      c.AutocertEmail,
+		}
+		server.TLSConfig = m.TLSConfig()
+	}
+	server.RegisterOnShutdown(func() { close(stopStreams) })
+	return server
+}
+
+// runServer serves until ctx is cancelled, then stops accepting connections
+// and waits up to the shutdown timeout for in-flight requests to finish.
+func runServer(ctx context.Context, c config.Server, server *http.Server) error {
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", server.Addr, "tls", c.TLSCertFile != "" || server.TLSConfig != nil)
+		var err error
+		switch {
+		case c.TLSCertFile != "":
+			err = server.ListenAndServeTLS(c.TLSCertFile, c.TLSKeyFile)
+		case server.TLSConfig != nil:
+			err = server.ListenAndServeTLS("", "")
+		default:
+			err = server.ListenAndServe()
+		}
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	slog.Info("shutting down", "timeout", c.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error draining connections: %w", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
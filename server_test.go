@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+)
+
+func TestNewServer(t *testing.T) {
+	c := config.Server{
+		Addr:              ":8080",
+		ReadHeaderTimeout: time.Second,
+		ReadTimeout:       2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    5000,
+		MaxBodyBytes:      10,
+	}
+	server := newServer(c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(413)
+		}
+	}), make(chan struct{}))
+	if server.Addr != c.Addr ||
+		server.ReadHeaderTimeout != c.ReadHeaderTimeout ||
+		server.ReadTimeout != c.ReadTimeout ||
+		server.WriteTimeout != c.WriteTimeout ||
+		server.IdleTimeout != c.IdleTimeout ||
+		server.MaxHeaderBytes != c.MaxHeaderBytes {
+		t.Errorf("server %+v doesn't match its config %+v", server, c)
+	}
+	if server.TLSConfig != nil {
+		t.Error("TLS is set up without certificates or autocert domains")
+	}
+
+	for body, want := range map[string]int{"say my name": 413, "yo": 200} {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Errorf("sending %q: status %d, want %d", body, rec.Code, want)
+		}
+	}
+}
+
+func TestServerShutdownEndsStreams(t *testing.T) {
+	cfg := newTestConfig(t)
+	stopStreams := make(chan struct{})
+	cfg.stopStreams = stopStreams
+	mux := http.NewServeMux()
+	handleRoutes(mux, cfg.routes(http.NotFoundHandler()))
+	// Streams outlive the write timeout.
+	c := config.Server{WriteTimeout: 100 * time.Millisecond, MaxBodyBytes: 1 << 20}
+	srv := httptest.NewUnstartedServer(nil)
+	srv.Config = newServer(c, cfg.middlewareAccountStatus(mux), stopStreams)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	events := openEventStream(t, srv, walt.Token, "/api/stream", "")
+	time.Sleep(3 * c.WriteTimeout)
+	nextEvent(t, events, postChirp(t, srv, walt.Token, "say my name"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("shutting down with a stream open: %v", err)
+	}
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Errorf("got %+v after shutting down, want the stream ended", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the stream is still open after shutting down")
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/Blustak/bootdev-chirpy/internal/service/chirps"
+	"github.com/Blustak/bootdev-chirpy/internal/service/sessions"
+	"github.com/Blustak/bootdev-chirpy/internal/service/users"
+)
+
+// initServices builds the services shared by the REST, GraphQL and gRPC
+// APIs, which only translate requests and errors, from cfg's storage and
+// settings.
+func (cfg *apiConfig) initServices() {
+	cfg.users = users.New(cfg.dbQueries)
+	cfg.chirps = chirps.New(cfg.dbQueries, cfg.publishChirp)
+	cfg.sessions = sessions.New(cfg.dbQueries, sessions.Config{
+		TokenSecret:    cfg.tokenSecret,
+		DeletionGrace:  cfg.accountDeletionGrace,
+		CancelDeletion: cfg.cancelAccountDeletion,
+		Notifier:       cfg.notifier,
+	})
+}
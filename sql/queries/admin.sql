@@ -0,0 +1,66 @@
+-- name: IsAdmin :one
+SELECT is_admin FROM users WHERE id = @id AND deleted_at IS NULL;
+
+-- name: SetUserAdmin :exec
+UPDATE users SET is_admin = @is_admin, updated_at = NOW() WHERE id = @id;
+
+-- name: AdminSearchUsers :many
+SELECT id, created_at, updated_at, email, is_chirpy_red, is_admin, status, suspended_until, status_reason, deleted_at FROM users
+WHERE (sqlc.narg('query')::text IS NULL OR strpos(lower(email), lower(sqlc.narg('query')::text)) > 0)
+AND (sqlc.narg('email_domain')::text IS NULL OR lower(split_part(email, '@', 2)) = lower(sqlc.narg('email_domain')::text))
+AND (sqlc.narg('is_chirpy_red')::boolean IS NULL OR is_chirpy_red = sqlc.narg('is_chirpy_red')::boolean)
+AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at >= sqlc.narg('created_after')::timestamptz)
+AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before')::timestamptz)
+ORDER BY created_at, id
+LIMIT @max_users OFFSET @skip;
+
+-- name: AdminGetUser :one
+SELECT users.id, users.created_at, users.updated_at, users.email, users.is_chirpy_red, users.is_admin, users.status, users.suspended_until, users.status_reason, users.deleted_at,
+(
+    SELECT COUNT(*) FROM refresh_tokens
+    WHERE refresh_tokens.user_id = users.id AND refresh_tokens.revoked_at IS NULL AND refresh_tokens.expires_at > NOW()
+) AS active_sessions,
+(
+    SELECT COUNT(*) FROM chirps
+    WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL
+) AS chirps
+FROM users WHERE users.id = @id;
+
+-- name: SetUserStatus :execrows
+UPDATE users
+SET status = @status, suspended_until = sqlc.narg('suspended_until'), status_reason = @status_reason, updated_at = NOW()
+WHERE id = @id;
+
+-- name: ListRestrictedUsers :many
+SELECT id, status, suspended_until, status_reason, deleted_at FROM users
+WHERE status = 'banned'
+OR (status = 'suspended' AND (suspended_until IS NULL OR suspended_until > NOW()))
+OR deleted_at IS NOT NULL;
+
+-- name: SignupsPerDay :many
+SELECT date_trunc('day', created_at AT TIME ZONE 'UTC')::timestamp AS day, COUNT(*) AS count FROM users
+WHERE created_at >= @since
+GROUP BY day
+ORDER BY day;
+
+-- name: ChirpsPerDay :many
+SELECT date_trunc('day', created_at AT TIME ZONE 'UTC')::timestamp AS day, COUNT(*) AS count FROM chirps
+WHERE created_at >= @since AND status = 'published' AND deleted_at IS NULL
+GROUP BY day
+ORDER BY day;
+
+-- name: CountActiveUsers :one
+SELECT COUNT(DISTINCT user_id) FROM (
+    SELECT chirps.user_id FROM chirps WHERE chirps.created_at >= @since
+    UNION ALL
+    SELECT refresh_tokens.user_id FROM refresh_tokens WHERE refresh_tokens.created_at >= @since
+) AS activity;
+
+-- name: CountUsers :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE is_chirpy_red) AS chirpy_red,
+    COUNT(*) FILTER (WHERE status = 'suspended' AND (suspended_until IS NULL OR suspended_until > NOW())) AS suspended,
+    COUNT(*) FILTER (WHERE status = 'banned') AS banned,
+    COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) AS pending_deletion
+FROM users;
@@ -0,0 +1,35 @@
+-- name: AddNotification :exec
+INSERT INTO notifications(id, created_at, user_id, type, payload)
+SELECT gen_random_uuid(), NOW(), @user_id::uuid, @type::text, @payload::jsonb
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_preferences
+    WHERE notification_preferences.user_id = @user_id::uuid
+    AND notification_preferences.type = @type::text
+    AND notification_preferences.enabled = FALSE
+);
+
+-- name: GetNotificationsForUser :many
+SELECT * FROM notifications
+WHERE user_id = @user_id
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size OFFSET @page_offset;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE user_id = @user_id AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE user_id = @user_id AND id = ANY(@ids::uuid[]) AND read_at IS NULL;
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE user_id = @user_id AND read_at IS NULL;
+
+-- name: GetNotificationPreferences :many
+SELECT * FROM notification_preferences WHERE user_id = @user_id;
+
+-- name: SetNotificationPreference :exec
+INSERT INTO notification_preferences(user_id, type, enabled, updated_at)
+VALUES(@user_id, @type, @enabled, NOW())
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_at = NOW();
@@ -24,6 +24,7 @@ UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = @
 
 -- name: GetUserByRefreshToken :one
 SELECT users.id,
+users.status,
 refresh_tokens.token,
 refresh_tokens.expires_at,
 refresh_tokens.revoked_at
@@ -31,3 +32,12 @@ FROM users
 LEFT JOIN refresh_tokens
 ON users.id = refresh_tokens.user_id
 WHERE refresh_tokens.token = @token;
+
+-- name: RevokeAllRefreshTokensForUser :exec
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = @user_id AND revoked_at IS NULL;
+
+-- name: GetRefreshTokensForUser :many
+SELECT created_at,updated_at,expires_at,revoked_at FROM refresh_tokens
+WHERE user_id = @user_id
+ORDER BY created_at ASC;
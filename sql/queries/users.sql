@@ -11,7 +11,7 @@ INSERT INTO users(id, created_at, updated_at, email,hashed_password) VALUES(
 DELETE FROM users;
 
 -- name: GetUserByEmail :one
-SELECT id,created_at,updated_at,email,is_chirpy_red FROM users WHERE email = @email;
+SELECT id,created_at,updated_at,email,is_chirpy_red,deleted_at,status,suspended_until,status_reason FROM users WHERE email = @email;
 
 -- name: GetHashedPasswordByID :one
 SELECT hashed_password FROM users WHERE id = @id;
@@ -24,7 +24,52 @@ updated_at = NOW()
 WHERE id = @user_id
 RETURNING id,created_at,updated_at,email,is_chirpy_red;
 
--- name: UpgradeUserToChirpyRed :exec
+-- name: UpgradeUserToChirpyRed :execrows
 UPDATE USERS
 SET is_chirpy_red = TRUE
 WHERE id = @user_id;
+
+-- name: GetUserByID :one
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users WHERE id = @id;
+
+-- name: GetUsersByIDs :many
+SELECT id,created_at,updated_at,email,is_chirpy_red FROM users
+WHERE id = ANY(@ids::uuid[]) AND deleted_at IS NULL;
+
+-- name: MarkUserDeleted :one
+UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = @id AND deleted_at IS NULL
+RETURNING deleted_at;
+
+-- name: CancelUserDeletion :exec
+UPDATE users SET deleted_at = NULL, updated_at = NOW() WHERE id = @id;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users WHERE deleted_at < @deleted_before;
+
+-- name: ListUsers :many
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users
+ORDER BY created_at, id
+LIMIT @max_users OFFSET @skip;
+
+-- name: SearchUsers :many
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users
+WHERE strpos(lower(email), lower(@query::text)) > 0
+ORDER BY email
+LIMIT @max_users;
+
+-- name: SetUserPassword :exec
+UPDATE users SET hashed_password = @hashed_password, updated_at = NOW() WHERE id = @id;
+
+-- name: DowngradeUserFromChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = FALSE
+WHERE id = @user_id;
+
+-- name: GetAllUsers :many
+SELECT * FROM users ORDER BY created_at, id;
+
+-- name: ImportUser :execrows
+INSERT INTO users(id, created_at, updated_at, email, hashed_password, is_chirpy_red)
+VALUES (@id, @created_at, @updated_at, @email, @hashed_password, @is_chirpy_red)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,49 @@
+-- +goose Up
+CREATE TABLE conversations(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE conversation_members(
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY(conversation_id, user_id)
+);
+CREATE INDEX conversation_members_user_id_idx ON conversation_members(user_id);
+
+CREATE TABLE direct_conversations(
+    user_a UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_b UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL UNIQUE REFERENCES conversations(id) ON DELETE CASCADE,
+    PRIMARY KEY(user_a, user_b)
+);
+
+CREATE TABLE messages(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+CREATE INDEX messages_conversation_id_created_at_idx ON messages(conversation_id, created_at DESC);
+
+ALTER TABLE users ADD COLUMN dm_allowlist_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE dm_allowlist(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    allowed_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(user_id, allowed_user_id)
+);
+
+-- +goose Down
+DROP TABLE dm_allowlist;
+ALTER TABLE users DROP COLUMN dm_allowlist_only;
+DROP TABLE messages;
+DROP TABLE direct_conversations;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX chirps_deleted_at_idx ON chirps(deleted_at) WHERE deleted_at IS NOT NULL;
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN deleted_at;
+DROP INDEX chirps_deleted_at_idx;
+ALTER TABLE chirps DROP COLUMN deleted_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'suspended', 'banned'));
+ALTER TABLE users ADD COLUMN suspended_until TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+CREATE INDEX users_restricted_idx ON users(status) WHERE status <> 'active';
+
+-- +goose Down
+DROP INDEX users_restricted_idx;
+ALTER TABLE users DROP COLUMN status_reason;
+ALTER TABLE users DROP COLUMN suspended_until;
+ALTER TABLE users DROP COLUMN status;
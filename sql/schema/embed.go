@@ -0,0 +1,10 @@
+// Package schema embeds the goose migrations that build the database, so
+// the server can apply them itself.
+package schema
+
+import "embed"
+
+// FS holds every migration, named NNN_description.sql.
+//
+//go:embed *.sql
+var FS embed.FS
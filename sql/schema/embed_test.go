@@ -0,0 +1,45 @@
+package schema_test
+
+import (
+	"database/sql"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/Blustak/bootdev-chirpy/sql/schema"
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+)
+
+func TestMigrationsAreEmbedded(t *testing.T) {
+	// Opening doesn't connect, and listing sources doesn't need a connection.
+	db, err := sql.Open("postgres", "postgres://localhost/chirpy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := provider.ListSources()
+	files, _ := fs.Glob(schema.FS, "*.sql")
+	if len(sources) != len(files) || len(sources) == 0 {
+		t.Fatalf("got %d migrations from %d files", len(sources), len(files))
+	}
+	for i, s := range sources {
+		if s.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d: versions must be consecutive", s.Path, s.Version, i+1)
+		}
+		data, err := fs.ReadFile(schema.FS, s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, annotation := range []string{"-- +goose Up", "-- +goose Down"} {
+			if !strings.Contains(string(data), annotation) {
+				t.Errorf("migration %s has no %q section", s.Path, annotation)
+			}
+		}
+	}
+}
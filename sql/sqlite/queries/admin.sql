@@ -0,0 +1,66 @@
+-- name: IsAdmin :one
+SELECT is_admin FROM users WHERE id = @id AND deleted_at IS NULL;
+
+-- name: SetUserAdmin :exec
+UPDATE users SET is_admin = @is_admin, updated_at = now() WHERE id = @id;
+
+-- name: AdminSearchUsers :many
+SELECT id, created_at, updated_at, email, is_chirpy_red, is_admin, status, suspended_until, status_reason, deleted_at FROM users
+WHERE (CAST(sqlc.narg('query') AS TEXT) IS NULL OR instr(lower(email), lower(CAST(sqlc.narg('query') AS TEXT))) > 0)
+AND (CAST(sqlc.narg('email_domain') AS TEXT) IS NULL OR lower(substr(email, instr(email, '@') + 1)) = lower(CAST(sqlc.narg('email_domain') AS TEXT)))
+AND (CAST(sqlc.narg('is_chirpy_red') AS BOOLEAN) IS NULL OR is_chirpy_red = CAST(sqlc.narg('is_chirpy_red') AS BOOLEAN))
+AND (CAST(sqlc.narg('created_after') AS TIMESTAMP) IS NULL OR created_at >= sqlc.narg('created_after'))
+AND (CAST(sqlc.narg('created_before') AS TIMESTAMP) IS NULL OR created_at < sqlc.narg('created_before'))
+ORDER BY created_at, id
+LIMIT @max_users OFFSET @skip;
+
+-- name: AdminGetUser :one
+SELECT users.id, users.created_at, users.updated_at, users.email, users.is_chirpy_red, users.is_admin, users.status, users.suspended_until, users.status_reason, users.deleted_at,
+(
+    SELECT COUNT(*) FROM refresh_tokens
+    WHERE refresh_tokens.user_id = users.id AND refresh_tokens.revoked_at IS NULL AND refresh_tokens.expires_at > now()
+) AS active_sessions,
+(
+    SELECT COUNT(*) FROM chirps
+    WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL
+) AS chirps
+FROM users WHERE users.id = @id;
+
+-- name: SetUserStatus :execrows
+UPDATE users
+SET status = @status, suspended_until = sqlc.narg('suspended_until'), status_reason = @status_reason, updated_at = now()
+WHERE id = @id;
+
+-- name: ListRestrictedUsers :many
+SELECT id, status, suspended_until, status_reason, deleted_at FROM users
+WHERE status = 'banned'
+OR (status = 'suspended' AND (suspended_until IS NULL OR suspended_until > now()))
+OR deleted_at IS NOT NULL;
+
+-- name: SignupsPerDay :many
+SELECT date(created_at) AS day, COUNT(*) AS count FROM users
+WHERE created_at >= @since
+GROUP BY day
+ORDER BY day;
+
+-- name: ChirpsPerDay :many
+SELECT date(created_at) AS day, COUNT(*) AS count FROM chirps
+WHERE created_at >= @since AND status = 'published' AND deleted_at IS NULL
+GROUP BY day
+ORDER BY day;
+
+-- name: CountActiveUsers :one
+SELECT COUNT(DISTINCT user_id) FROM (
+    SELECT chirps.user_id FROM chirps WHERE chirps.created_at >= @since
+    UNION ALL
+    SELECT refresh_tokens.user_id FROM refresh_tokens WHERE refresh_tokens.created_at >= @since
+) AS activity;
+
+-- name: CountUsers :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE is_chirpy_red) AS chirpy_red,
+    COUNT(*) FILTER (WHERE status = 'suspended' AND (suspended_until IS NULL OR suspended_until > now())) AS suspended,
+    COUNT(*) FILTER (WHERE status = 'banned') AS banned,
+    COUNT(*) FILTER (WHERE deleted_at IS NOT NULL) AS pending_deletion
+FROM users;
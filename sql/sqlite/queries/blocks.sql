@@ -0,0 +1,26 @@
+-- name: BlockUser :exec
+INSERT INTO user_blocks(blocker_id, blocked_id, created_at)
+VALUES(@blocker_id, @blocked_id, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM user_blocks WHERE blocker_id = @blocker_id AND blocked_id = @blocked_id;
+
+-- name: MuteUser :exec
+INSERT INTO user_mutes(muter_id, muted_id, created_at)
+VALUES(@muter_id, @muted_id, now())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM user_mutes WHERE muter_id = @muter_id AND muted_id = @muted_id;
+
+-- name: IsBlockedByAnyEmail :one
+SELECT COUNT(*) > 0 AS blocked FROM user_blocks
+JOIN (SELECT id, lower(email) AS email FROM users) AS blockers ON blockers.id = user_blocks.blocker_id
+WHERE user_blocks.blocked_id = @user_id
+AND blockers.email IN (sqlc.slice('emails'));
+
+-- name: GetHiddenAuthors :many
+SELECT blocker_id AS author_id FROM user_blocks WHERE blocked_id = @viewer_id
+UNION
+SELECT muted_id AS author_id FROM user_mutes WHERE muter_id = @viewer_id;
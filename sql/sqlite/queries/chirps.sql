@@ -0,0 +1,168 @@
+-- name: AddChirp :one
+INSERT INTO chirps(id,created_at,updated_at,body,user_id) VALUES(
+    gen_random_uuid(),
+    now(),
+    now(),
+    @chirp_body,
+    @id
+) RETURNING *;
+
+-- name: GetAllChirps :many
+SELECT * FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND chirps.user_id NOT IN (
+    SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = sqlc.narg('viewer_id')
+)
+AND chirps.user_id NOT IN (
+    SELECT user_mutes.muted_id FROM user_mutes WHERE user_mutes.muter_id = sqlc.narg('viewer_id')
+)
+ORDER BY created_at ASC;
+
+-- name: GetChirpByID :one
+SELECT * FROM chirps
+WHERE id = @id
+AND deleted_at IS NULL
+AND (status = 'published' OR user_id = sqlc.narg('viewer_id'))
+AND chirps.user_id NOT IN (
+    SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = sqlc.narg('viewer_id')
+);
+
+-- name: GetChirpsFromUser :many
+SELECT * FROM chirps
+WHERE user_id = @author_id
+AND status = 'published'
+AND deleted_at IS NULL
+AND chirps.user_id NOT IN (
+    SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = sqlc.narg('viewer_id')
+)
+AND chirps.user_id NOT IN (
+    SELECT user_mutes.muted_id FROM user_mutes WHERE user_mutes.muter_id = sqlc.narg('viewer_id')
+)
+ORDER BY created_at ASC;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND (status = 'published' OR user_id = sqlc.narg('viewer_id'))
+AND chirps.user_id NOT IN (
+    SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = sqlc.narg('viewer_id')
+)
+AND id IN (sqlc.slice('ids'));
+
+-- name: GetRecentChirpsByAuthors :many
+SELECT * FROM chirps
+WHERE status = 'published'
+AND deleted_at IS NULL
+AND (
+    SELECT COUNT(*) FROM chirps newer
+    WHERE newer.user_id = chirps.user_id
+    AND newer.status = 'published'
+    AND newer.deleted_at IS NULL
+    AND (newer.created_at, newer.id) > (chirps.created_at, chirps.id)
+) < CAST(@max_per_author AS INTEGER)
+AND chirps.user_id NOT IN (
+    SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = sqlc.narg('viewer_id')
+)
+AND chirps.user_id NOT IN (
+    SELECT user_mutes.muted_id FROM user_mutes WHERE user_mutes.muter_id = sqlc.narg('viewer_id')
+)
+AND chirps.user_id IN (sqlc.slice('author_ids'))
+ORDER BY user_id, created_at DESC, id DESC;
+
+-- name: DeleteChirpByID :exec
+UPDATE chirps SET deleted_at = now(), updated_at = now()
+WHERE id = @chirpID AND deleted_at IS NULL;
+
+-- name: GetChirpsAfter :many
+SELECT * FROM chirps
+WHERE (created_at, id) > (SELECT c.created_at, c.id FROM chirps c WHERE c.id = @last_id)
+AND status = 'published'
+AND deleted_at IS NULL
+AND (CAST(@author_ids AS TEXT) = '' OR instr(CAST(@author_ids AS TEXT), chirps.user_id) > 0)
+AND chirps.user_id NOT IN (
+    SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = sqlc.narg('viewer_id')
+)
+AND chirps.user_id NOT IN (
+    SELECT user_mutes.muted_id FROM user_mutes WHERE user_mutes.muter_id = sqlc.narg('viewer_id')
+)
+ORDER BY created_at ASC, id ASC
+LIMIT @max_chirps;
+
+-- name: AddUnpublishedChirp :one
+INSERT INTO chirps(id,created_at,updated_at,body,user_id,status,publish_at) VALUES(
+    gen_random_uuid(),
+    now(),
+    now(),
+    @chirp_body,
+    @user_id,
+    @status,
+    sqlc.narg('publish_at')
+) RETURNING *;
+
+-- name: GetUnpublishedChirpsForUser :many
+SELECT * FROM chirps
+WHERE user_id = @user_id AND status <> 'published' AND deleted_at IS NULL
+ORDER BY publish_at ASC NULLS LAST, created_at ASC;
+
+-- name: DeleteUnpublishedChirp :execrows
+DELETE FROM chirps WHERE id = @id AND user_id = @user_id AND status <> 'published';
+
+-- name: PublishChirp :one
+UPDATE chirps
+SET status = 'published', publish_at = NULL, created_at = now(), updated_at = now()
+WHERE id = @id AND user_id = @user_id AND status <> 'published' AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET status = 'published', publish_at = NULL, created_at = now(), updated_at = now()
+WHERE id IN (
+    SELECT due.id FROM chirps due
+    WHERE due.status = 'scheduled' AND due.publish_at <= now() AND due.deleted_at IS NULL
+    ORDER BY due.publish_at ASC
+    LIMIT @batch_size
+)
+AND status = 'scheduled'
+RETURNING *;
+
+-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, updated_at = now()
+WHERE id = @id AND user_id = @user_id AND deleted_at >= @deleted_after
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE chirps.deleted_at < @deleted_before
+AND NOT EXISTS (
+    SELECT 1 FROM users WHERE users.id = chirps.user_id AND users.deleted_at IS NOT NULL
+);
+
+-- name: SoftDeleteChirpsForUser :exec
+UPDATE chirps SET deleted_at = @deleted_at, updated_at = now()
+WHERE user_id = @user_id AND deleted_at IS NULL;
+
+-- name: RestoreChirpsForUser :exec
+UPDATE chirps SET deleted_at = NULL, updated_at = now()
+WHERE user_id = @user_id AND deleted_at = @deleted_at;
+
+-- name: GetAllChirpsForUser :many
+SELECT * FROM chirps WHERE user_id = @user_id ORDER BY created_at ASC;
+
+-- name: AdminDeleteChirp :one
+UPDATE chirps SET deleted_at = now(), updated_at = now()
+WHERE id = @id AND deleted_at IS NULL
+RETURNING *;
+
+-- name: AdminRestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, updated_at = now()
+WHERE id = @id AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: GetAllChirpsForExport :many
+SELECT * FROM chirps WHERE deleted_at IS NULL ORDER BY created_at, id;
+
+-- name: ImportChirp :execrows
+INSERT INTO chirps(id, created_at, updated_at, body, user_id, status, publish_at)
+VALUES (@id, @created_at, @updated_at, @body, @user_id, @status, @publish_at)
+ON CONFLICT DO NOTHING;
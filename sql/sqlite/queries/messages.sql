@@ -0,0 +1,101 @@
+-- name: CreateConversation :one
+INSERT INTO conversations(id, created_at, updated_at, created_by) VALUES(
+    gen_random_uuid(),
+    now(),
+    now(),
+    @created_by
+) RETURNING *;
+
+-- name: AddConversationMember :exec
+INSERT INTO conversation_members(conversation_id, user_id, joined_at)
+VALUES(@conversation_id, @user_id, now());
+
+-- name: GetDirectConversation :one
+SELECT conversations.* FROM conversations
+JOIN direct_conversations ON direct_conversations.conversation_id = conversations.id
+WHERE direct_conversations.user_a = @user_a AND direct_conversations.user_b = @user_b;
+
+-- name: AddDirectConversation :execrows
+INSERT INTO direct_conversations(user_a, user_b, conversation_id)
+VALUES(@user_a, @user_b, @conversation_id)
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationsForUser :many
+SELECT conversations.id,
+conversations.created_at,
+conversations.updated_at,
+conversations.created_by,
+conversation_members.last_read_at,
+(
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.sender_id <> @user_id
+    AND (conversation_members.last_read_at IS NULL OR messages.created_at > conversation_members.last_read_at)
+) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = @user_id
+ORDER BY conversations.updated_at DESC;
+
+-- name: GetConversationMembers :many
+SELECT * FROM conversation_members WHERE conversation_id = @conversation_id ORDER BY joined_at ASC;
+
+-- name: IsConversationMember :one
+SELECT COUNT(*) > 0 AS is_member FROM conversation_members
+WHERE conversation_id = @conversation_id AND user_id = @user_id;
+
+-- name: AddMessage :one
+INSERT INTO messages(id, created_at, conversation_id, sender_id, body) VALUES(
+    gen_random_uuid(),
+    now(),
+    @conversation_id,
+    @sender_id,
+    @body
+) RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations SET updated_at = now() WHERE id = @id;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = @conversation_id
+ORDER BY created_at DESC
+LIMIT @page_size OFFSET @page_offset;
+
+-- name: MarkConversationRead :exec
+UPDATE conversation_members SET last_read_at = now()
+WHERE conversation_id = @conversation_id AND user_id = @user_id;
+
+-- name: CanSendDirectMessage :one
+SELECT (
+    (
+        NOT users.dm_allowlist_only
+        OR users.id IN (
+            SELECT dm_allowlist.user_id FROM dm_allowlist WHERE dm_allowlist.allowed_user_id = @sender_id
+        )
+    )
+    AND users.id NOT IN (
+        SELECT user_blocks.blocker_id FROM user_blocks WHERE user_blocks.blocked_id = @sender_id
+    )
+) AS allowed
+FROM users WHERE users.id = @recipient_id;
+
+-- name: GetDMAllowlistOnly :one
+SELECT dm_allowlist_only FROM users WHERE id = @user_id;
+
+-- name: SetDMAllowlistOnly :exec
+UPDATE users SET dm_allowlist_only = @allowlist_only, updated_at = now() WHERE id = @user_id;
+
+-- name: GetDMAllowlist :many
+SELECT * FROM dm_allowlist WHERE user_id = @user_id ORDER BY created_at ASC;
+
+-- name: AddToDMAllowlist :exec
+INSERT INTO dm_allowlist(user_id, allowed_user_id, created_at)
+VALUES(@user_id, @allowed_user_id, now())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveFromDMAllowlist :exec
+DELETE FROM dm_allowlist WHERE user_id = @user_id AND allowed_user_id = @allowed_user_id;
+
+-- name: GetMessagesSentByUser :many
+SELECT * FROM messages WHERE sender_id = @sender_id ORDER BY created_at ASC;
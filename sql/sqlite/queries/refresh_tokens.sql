@@ -0,0 +1,43 @@
+-- name: AddRefreshToken :one
+INSERT INTO refresh_tokens(
+    token,
+    created_at,
+    updated_at,
+    user_id,
+    expires_at,
+    revoked_at
+    ) VALUES(
+    @token,
+    now(),
+    now(),
+    @user_id,
+    datetime(now(), '+60 days'),
+    NULL
+) RETURNING *;
+
+
+-- name: GetRefreshToken :one
+SELECT token,expires_at,revoked_at FROM refresh_tokens WHERE token = @token;
+
+-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now() WHERE token = @token;
+
+-- name: GetUserByRefreshToken :one
+SELECT users.id,
+users.status,
+refresh_tokens.token,
+refresh_tokens.expires_at,
+refresh_tokens.revoked_at
+FROM users
+LEFT JOIN refresh_tokens
+ON users.id = refresh_tokens.user_id
+WHERE refresh_tokens.token = @token;
+
+-- name: RevokeAllRefreshTokensForUser :exec
+UPDATE refresh_tokens SET revoked_at = now(), updated_at = now()
+WHERE user_id = @user_id AND revoked_at IS NULL;
+
+-- name: GetRefreshTokensForUser :many
+SELECT created_at,updated_at,expires_at,revoked_at FROM refresh_tokens
+WHERE user_id = @user_id
+ORDER BY created_at ASC;
@@ -0,0 +1,75 @@
+-- name: CreateUser :one
+INSERT INTO users(id, created_at, updated_at, email,hashed_password) VALUES(
+    gen_random_uuid(),
+    now(),
+    now(),
+    ?,
+    ?
+) RETURNING id,created_at,updated_at,email,is_chirpy_red;
+
+-- name: ResetUserTable :exec
+DELETE FROM users;
+
+-- name: GetUserByEmail :one
+SELECT id,created_at,updated_at,email,is_chirpy_red,deleted_at,status,suspended_until,status_reason FROM users WHERE email = @email;
+
+-- name: GetHashedPasswordByID :one
+SELECT hashed_password FROM users WHERE id = @id;
+
+-- name: UpdateUser :one
+UPDATE users
+SET email = @email,
+hashed_password = @hashed_password,
+updated_at = now()
+WHERE id = @user_id
+RETURNING id,created_at,updated_at,email,is_chirpy_red;
+
+-- name: UpgradeUserToChirpyRed :execrows
+UPDATE users
+SET is_chirpy_red = TRUE
+WHERE id = @user_id;
+
+-- name: GetUserByID :one
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users WHERE id = @id;
+
+-- name: GetUsersByIDs :many
+SELECT id,created_at,updated_at,email,is_chirpy_red FROM users
+WHERE id IN (sqlc.slice('ids')) AND deleted_at IS NULL;
+
+-- name: MarkUserDeleted :one
+UPDATE users SET deleted_at = now(), updated_at = now()
+WHERE id = @id AND deleted_at IS NULL
+RETURNING deleted_at;
+
+-- name: CancelUserDeletion :exec
+UPDATE users SET deleted_at = NULL, updated_at = now() WHERE id = @id;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users WHERE deleted_at < @deleted_before;
+
+-- name: ListUsers :many
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users
+ORDER BY created_at, id
+LIMIT @max_users OFFSET @skip;
+
+-- name: SearchUsers :many
+SELECT id,created_at,updated_at,email,is_chirpy_red,dm_allowlist_only,deleted_at FROM users
+WHERE instr(lower(email), lower(CAST(@query AS TEXT))) > 0
+ORDER BY email
+LIMIT @max_users;
+
+-- name: SetUserPassword :exec
+UPDATE users SET hashed_password = @hashed_password, updated_at = now() WHERE id = @id;
+
+-- name: DowngradeUserFromChirpyRed :exec
+UPDATE users
+SET is_chirpy_red = FALSE
+WHERE id = @user_id;
+
+-- name: GetAllUsers :many
+SELECT * FROM users ORDER BY created_at, id;
+
+-- name: ImportUser :execrows
+INSERT INTO users(id, created_at, updated_at, email, hashed_password, is_chirpy_red)
+VALUES (@id, @created_at, @updated_at, @email, @hashed_password, @is_chirpy_red)
+ON CONFLICT DO NOTHING;
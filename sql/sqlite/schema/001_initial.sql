@@ -0,0 +1,128 @@
+-- +goose Up
+-- The SQLite schema matches the Postgres one at sql/schema/012, column for
+-- column and in the same order, so sqlc generates the same models for both.
+-- UUIDs are stored as text and timestamps as UTC text, which the driver
+-- parses back into time.Time for the columns declared TIMESTAMP.
+CREATE TABLE users(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    hashed_password TEXT NOT NULL DEFAULT 'unset',
+    is_chirpy_red BOOLEAN NOT NULL DEFAULT FALSE,
+    dm_allowlist_only BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMP,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'banned')),
+    suspended_until TIMESTAMP,
+    status_reason TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX users_restricted_idx ON users(status) WHERE status <> 'active';
+
+CREATE TABLE chirps(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    body TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published')),
+    publish_at TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+CREATE INDEX chirps_scheduled_publish_at_idx ON chirps(publish_at) WHERE status = 'scheduled';
+CREATE INDEX chirps_deleted_at_idx ON chirps(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE refresh_tokens(
+    token TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP
+);
+CREATE INDEX notifications_user_id_created_at_idx ON notifications(user_id, created_at DESC);
+
+CREATE TABLE notification_preferences(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(user_id, type)
+);
+
+CREATE TABLE conversations(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE conversation_members(
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY(conversation_id, user_id)
+);
+CREATE INDEX conversation_members_user_id_idx ON conversation_members(user_id);
+
+CREATE TABLE direct_conversations(
+    user_a UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_b UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL UNIQUE REFERENCES conversations(id) ON DELETE CASCADE,
+    PRIMARY KEY(user_a, user_b)
+);
+
+CREATE TABLE messages(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+CREATE INDEX messages_conversation_id_created_at_idx ON messages(conversation_id, created_at DESC);
+
+CREATE TABLE dm_allowlist(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    allowed_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(user_id, allowed_user_id)
+);
+
+CREATE TABLE user_blocks(
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(blocker_id, blocked_id)
+);
+CREATE INDEX user_blocks_blocked_id_idx ON user_blocks(blocked_id);
+
+CREATE TABLE user_mutes(
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(muter_id, muted_id)
+);
+
+-- +goose Down
+DROP TABLE user_mutes;
+DROP TABLE user_blocks;
+DROP TABLE dm_allowlist;
+DROP TABLE messages;
+DROP TABLE direct_conversations;
+DROP TABLE conversation_members;
+DROP TABLE conversations;
+DROP TABLE notification_preferences;
+DROP TABLE notifications;
+DROP TABLE refresh_tokens;
+DROP TABLE chirps;
+DROP TABLE users;
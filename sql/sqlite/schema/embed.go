@@ -0,0 +1,10 @@
+// Package schema embeds the goose migrations that build a SQLite database,
+// the counterpart of the Postgres ones in sql/schema.
+package schema
+
+import "embed"
+
+// FS holds every migration, named NNN_description.sql.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/config"
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/Blustak/bootdev-chirpy/internal/database/sqlite"
+	"github.com/Blustak/bootdev-chirpy/internal/metrics"
+	"github.com/Blustak/bootdev-chirpy/internal/pubsub"
+	"github.com/Blustak/bootdev-chirpy/sql/schema"
+	sqliteschema "github.com/Blustak/bootdev-chirpy/sql/sqlite/schema"
+	"github.com/pressly/goose/v3"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// storage is the database Chirpy keeps its data in: Postgres, or a SQLite
+// file for running without a database server. The scheme of DB_URL picks
+// which.
+type storage struct {
+	db *sql.DB
+	// system is the database's semconv db.system, for the query spans.
+	system attribute.KeyValue
+	// newStore returns the queries run through db, the database or a
+	// transaction on it.
+	newStore   func(db database.DBTX) database.Store
+	dialect    goose.Dialect
+	migrations fs.FS
+}
+
+// openStorage opens the database dbURL names: postgres://...,
+// postgresql://... or a libpq key=value string for Postgres, and sqlite:FILE
+// or sqlite:///FILE for SQLite.
+func openStorage(dbURL string) (*storage, error) {
+	if config.IsKeywordDSN(dbURL) {
+		return openPostgres(dbURL)
+	}
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return nil, errors.New("DB_URL is not a URL")
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		return openPostgres(dbURL)
+	case "sqlite":
+		path := u.Opaque
+		if path == "" {
+			path = u.Path
+		}
+		db, err := sqlite.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return sqliteStorage(db), nil
+	}
+	return nil, fmt.Errorf("unsupported database %q", u.Scheme)
+}
+
+func openPostgres(dsn string) (*storage, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return postgresStorage(db), nil
+}
+
+func postgresStorage(db *sql.DB) *storage {
+	return &storage{
+		db:     db,
+		system: semconv.DBSystemPostgreSQL,
+		newStore: func(db database.DBTX) database.Store {
+			return database.New(db)
+		},
+		dialect:    goose.DialectPostgres,
+		migrations: schema.FS,
+	}
+}
+
+// sqliteStorage wraps a database opened with sqlite.Open.
+func sqliteStorage(db *sql.DB) *storage {
+	return &storage{
+		db:     db,
+		system: semconv.DBSystemSqlite,
+		newStore: func(db database.DBTX) database.Store {
+			return sqlite.NewStore(db)
+		},
+		dialect:    goose.DialectSQLite3,
+		migrations: sqliteschema.FS,
+	}
+}
+
+// ping checks that the database can be reached, so that a wrong DB_URL is
+// reported straight away rather than on the first query.
+func (s *storage) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("can't reach the database: %w", err)
+	}
+	return nil
+}
+
+// queries returns the queries run through db, the database or a transaction
+// on it, traced and timed.
+func (s *storage) queries(db database.DBTX) database.Store {
+	return s.newStore(database.Trace(database.Instrument(db, metrics.ObserveQuery), s.system))
+}
+
+// newBus returns the bus the chirp streams are fed from. On Postgres it's
+// LISTEN/NOTIFY, so that every replica hears of every chirp; a SQLite file
+// is served by one process, so its bus is in memory.
+func (s *storage) newBus(dbURL string) pubsub.Bus {
+	if s.dialect == goose.DialectPostgres {
+		return pubsub.NewPostgresBus(s.db, dbURL)
+	}
+	return pubsub.NewMemoryBus()
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	chirpsChannel     = "chirps"
+	maxReplayedChirps = 500
+	streamKeepAlive   = 25 * time.Second
+	// streamWriteTimeout replaces the server's write timeout, which would
+	// otherwise cut every stream off, for each write to a stream.
+	streamWriteTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type streamEvent struct {
+	ID   uuid.UUID       `json:"id"`
+	Type string          `json:"event"`
+	Data json.RawMessage `json:"data"`
+}
+
+// streamFilter selects which chirps a stream receives. An empty author set
+// means the whole public feed. Authors who blocked the viewer or whom the
+// viewer muted are always left out; hidden is reloaded with every
+// keep-alive, so a block or mute takes effect on open streams within
+// streamKeepAlive.
+type streamFilter struct {
+	authors map[uuid.UUID]bool
+	hidden  map[uuid.UUID]bool
+}
+
+func (f streamFilter) match(authorID uuid.UUID) bool {
+	if f.hidden[authorID] {
+		return false
+	}
+	return len(f.authors) == 0 || f.authors[authorID]
+}
+
+// authorIDs returns the authors the stream is limited to, none for all.
+func (f streamFilter) authorIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(f.authors))
+	for id := range f.authors {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func parseStreamFilter(r *http.Request) (streamFilter, error) {
+	f := streamFilter{authors: map[uuid.UUID]bool{}, hidden: map[uuid.UUID]bool{}}
+	for _, v := range r.URL.Query()["author_id"] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return f, fmt.Errorf("invalid author_id: %s", s)
+			}
+			f.authors[id] = true
+		}
+	}
+	return f, nil
+}
+
+func chirpStreamEvent(c database.Chirp) (streamEvent, error) {
+	data, err := json.Marshal(Chirp{Chirp: c})
+	if err != nil {
+		return streamEvent{}, err
+	}
+	return streamEvent{ID: c.ID, Type: "chirp", Data: data}, nil
+}
+
+// hiddenAuthors returns the authors whose chirps the viewer isn't shown.
+func (cfg *apiConfig) hiddenAuthors(ctx context.Context, viewerID uuid.UUID) (map[uuid.UUID]bool, error) {
+	ids, err := cfg.dbQueries.GetHiddenAuthors(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		hidden[id] = true
+	}
+	return hidden, nil
+}
+
+// publishChirp announces a new chirp to every stream, on every instance.
+func (cfg *apiConfig) publishChirp(ctx context.Context, c database.Chirp) {
+	data, err := json.Marshal(Chirp{Chirp: c})
+	if err != nil {
+		slog.ErrorContext(ctx, "error marshalling chirp for stream", "error", err)
+		return
+	}
+	if err := cfg.bus.Publish(ctx, chirpsChannel, data); err != nil {
+		slog.ErrorContext(ctx, "error publishing chirp", "chirp_id", c.ID, "error", err)
+	}
+}
+
+func (cfg *apiConfig) streamHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.authenticate(r)
+	if err != nil {
+		clientErrorResponse(w, 401, err)
+		return
+	}
+	filter, err := parseStreamFilter(r)
+	if err != nil {
+		clientErrorResponse(w, 400, err)
+		return
+	}
+	if filter.hidden, err = cfg.hiddenAuthors(r.Context(), userID); err != nil {
+		serverErrorResponse(w, 500, err)
+		return
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uuid.UUID
+	if lastEventID != "" {
+		if lastID, err = uuid.Parse(lastEventID); err != nil {
+			clientErrorResponse(w, 400, fmt.Errorf("invalid Last-Event-ID: %s", lastEventID))
+			return
+		}
+	}
+
+	// Subscribe before replaying so nothing published in between is lost.
+	live, unsubscribe := cfg.bus.Subscribe(chirpsChannel)
+	defer unsubscribe()
+
+	var replay []database.Chirp
+	if lastID != uuid.Nil {
+		replay, err = cfg.dbQueries.GetChirpsAfter(r.Context(), database.GetChirpsAfterParams{
+			LastID:    lastID,
+			AuthorIds: filter.authorIDs(),
+			ViewerID:  uuid.NullUUID{UUID: userID, Valid: true},
+			MaxChirps: maxReplayedChirps,
+		})
+		if err != nil {
+			serverErrorResponse(w, 500, err)
+			return
+		}
+	}
+
+	var send func(streamEvent) error
+	var keepAlive func() error
+	if websocket.IsWebSocketUpgrade(r) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			slog.InfoContext(r.Context(), "error upgrading stream to websocket", "error", err)
+			return
+		}
+		defer conn.Close()
+		// The hijacked connection keeps the server's read deadline.
+		conn.SetReadDeadline(time.Time{})
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		r = r.WithContext(ctx)
+		// Reading is required to process control frames and notice closes.
+		go func() {
+			defer cancel()
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		send = func(ev streamEvent) error {
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			return conn.WriteJSON(ev)
+		}
+		keepAlive = func() error {
+			return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second))
+		}
+	} else {
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+			serverErrorResponse(w, 500, fmt.Errorf("streaming unsupported: %w", err))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(200)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		write := func(format string, args ...any) error {
+			if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, format, args...); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		send = func(ev streamEvent) error {
+			return write("id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+		}
+		keepAlive = func() error {
+			return write(": keep-alive\n\n")
+		}
+	}
+
+	sent := map[uuid.UUID]bool{}
+	for _, c := range replay {
+		if !filter.match(c.UserID) {
+			continue
+		}
+		ev, err := chirpStreamEvent(c)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error building stream event", "error", err)
+			continue
+		}
+		if err := send(ev); err != nil {
+			return
+		}
+		sent[c.ID] = true
+	}
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-cfg.stopStreams:
+			return
+		case <-ticker.C:
+			if err := keepAlive(); err != nil {
+				return
+			}
+			hidden, err := cfg.hiddenAuthors(r.Context(), userID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "error reloading hidden authors for stream", "error", err)
+				continue
+			}
+			filter.hidden = hidden
+		case payload, ok := <-live:
+			if !ok {
+				return
+			}
+			var c struct {
+				ID     uuid.UUID `json:"id"`
+				UserID uuid.UUID `json:"user_id"`
+			}
+			if err := json.Unmarshal(payload, &c); err != nil {
+				slog.ErrorContext(r.Context(), "error decoding stream event", "error", err)
+				continue
+			}
+			if sent[c.ID] || !filter.match(c.UserID) {
+				continue
+			}
+			if err := send(streamEvent{ID: c.ID, Type: "chirp", Data: payload}); err != nil {
+				return
+			}
+		}
+	}
+}
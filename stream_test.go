@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// openEventStream opens an SSE stream at path and returns the events it
+// sends, read as a browser would.
+func openEventStream(t *testing.T, srv *httptest.Server, token, path, lastEventID string) <-chan streamEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+path, nil)
+	req.Header.Set("Authorization", bearer(token))
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		t.Fatalf("GET %s: status %d", path, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type %q, want text/event-stream", ct)
+	}
+
+	events := make(chan streamEvent)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+		var ev streamEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if ev.Type == "" {
+					continue
+				}
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+				ev = streamEvent{}
+			case strings.HasPrefix(line, ":"):
+			case strings.HasPrefix(line, "id: "):
+				ev.ID, _ = uuid.Parse(strings.TrimPrefix(line, "id: "))
+			case strings.HasPrefix(line, "event: "):
+				ev.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.Data = json.RawMessage(strings.TrimPrefix(line, "data: "))
+			default:
+				t.Errorf("unexpected stream line %q", line)
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent waits for the next event and checks that it announces want.
+func nextEvent(t *testing.T, events <-chan streamEvent, want testChirp) {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatalf("the stream ended waiting for %q", want.Body)
+		}
+		var got testChirp
+		if err := json.Unmarshal(ev.Data, &got); err != nil {
+			t.Fatalf("event data %q: %v", ev.Data, err)
+		}
+		if ev.Type != "chirp" || ev.ID != want.ID || got != want {
+			t.Fatalf("got %s event %s for %+v, want %q", ev.Type, ev.ID, got, want.Body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %q", want.Body)
+	}
+}
+
+func TestStreamSSE(t *testing.T) {
+	srv := newTestServer(t)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	jesse := signUp(t, srv, "jesse@breakingbad.com", "yeahscience1")
+	hank := signUp(t, srv, "hank@dea.gov", "minerals1")
+	first := postChirp(t, srv, walt.Token, "say my name")
+	postChirp(t, srv, jesse.Token, "yeah science")
+	second := postChirp(t, srv, walt.Token, "I am the one who knocks")
+	postChirp(t, srv, jesse.Token, "yeah magnets")
+
+	for _, tt := range []struct {
+		name, token, path string
+		want              int
+	}{
+		{"anonymous", "", "/api/stream", 401},
+		{"bad author", hank.Token, "/api/stream?author_id=heisenberg", 400},
+		{"bad last event", hank.Token, "/api/stream?last_event_id=heisenberg", 400},
+	} {
+		if status := apiRequest(t, srv, "GET", tt.path, bearer(tt.token), nil, nil); status != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, status, tt.want)
+		}
+	}
+
+	// Only Walt's chirps since the last event are replayed, then only his
+	// new ones are sent.
+	waltsEvents := openEventStream(t, srv, hank.Token, "/api/stream?author_id="+walt.ID.String(), first.ID.String())
+	nextEvent(t, waltsEvents, second)
+	postChirp(t, srv, jesse.Token, "science, bitch")
+	third := postChirp(t, srv, walt.Token, "tread lightly")
+	nextEvent(t, waltsEvents, third)
+
+	// Muted authors are left out of the whole feed.
+	if status := apiRequest(t, srv, "POST", "/api/users/"+jesse.ID.String()+"/mute", bearer(hank.Token), nil, nil); status != 204 {
+		t.Fatalf("muting: status %d", status)
+	}
+	allEvents := openEventStream(t, srv, hank.Token, "/api/stream", first.ID.String())
+	nextEvent(t, allEvents, second)
+	nextEvent(t, allEvents, third)
+	postChirp(t, srv, jesse.Token, "we need to cook")
+	fourth := postChirp(t, srv, walt.Token, "stay out of my territory")
+	nextEvent(t, allEvents, fourth)
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	srv := newTestServer(t)
+	walt := signUp(t, srv, "walt@breakingbad.com", "heisenberg1")
+	hank := signUp(t, srv, "hank@dea.gov", "minerals1")
+	first := postChirp(t, srv, walt.Token, "say my name")
+	second := postChirp(t, srv, walt.Token, "I am the one who knocks")
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/stream?last_event_id=" + first.ID.String()
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != 401 {
+		t.Fatalf("connecting anonymously: err = %v, want a 401", err)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {bearer(hank.Token)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	next := func(want testChirp) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var ev streamEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("waiting for %q: %v", want.Body, err)
+		}
+		var got testChirp
+		if err := json.Unmarshal(ev.Data, &got); err != nil {
+			t.Fatalf("event data %q: %v", ev.Data, err)
+		}
+		if ev.Type != "chirp" || ev.ID != want.ID || got != want {
+			t.Fatalf("got %s event %s for %+v, want %q", ev.Type, ev.ID, got, want.Body)
+		}
+	}
+	next(second)
+	next(postChirp(t, srv, walt.Token, "tread lightly"))
+}
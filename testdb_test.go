@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Blustak/bootdev-chirpy/internal/database/sqlite"
+	"github.com/google/uuid"
+)
+
+// The integration tests run against a throwaway Postgres: the server named
+// by CHIRPY_TEST_DB_URL, as a user allowed to create databases, or else one
+// started for the test run from the initdb and postgres binaries on PATH or
+// under /usr/lib/postgresql. Without either they run on SQLite instead.
+//
+// On Postgres the migrations are applied once, to a template database, and
+// every test gets a copy of it of its own, dropped when the test ends. On
+// SQLite every test migrates a file of its own.
+
+// testPostgres is the server the tests' databases are made on.
+type testPostgres struct {
+	// admin is connected to a database other than the template, to create
+	// and drop the others.
+	admin *sql.DB
+	// dsn is the connection string for the named database.
+	dsn      func(dbname string) string
+	template string
+	stop     func()
+	// mu serializes copying the template, which fails if the template is
+	// being copied already.
+	mu sync.Mutex
+}
+
+// errNoPostgres is why there's no server to test against.
+var errNoPostgres = errors.New("no postgres to test against")
+
+var (
+	testPGOnce sync.Once
+	testPG     *testPostgres
+	testPGErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testPG != nil {
+		testPG.close()
+	}
+	os.Exit(code)
+}
+
+// newTestDB returns a migrated database of the test's own, on SQLite if
+// there's no Postgres to make one on.
+func newTestDB(t *testing.T) *storage {
+	t.Helper()
+	testPGOnce.Do(func() { testPG, testPGErr = startTestPostgres() })
+	if errors.Is(testPGErr, errNoPostgres) {
+		return newTestSQLiteDB(t)
+	}
+	if testPGErr != nil {
+		t.Fatal(testPGErr)
+	}
+	pg := testPG
+
+	name := "chirpy_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	pg.mu.Lock()
+	_, err := pg.admin.Exec("CREATE DATABASE " + name + " TEMPLATE " + pg.template)
+	pg.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("postgres", pg.dsn(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := pg.admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)"); err != nil {
+			t.Errorf("dropping %s: %v", name, err)
+		}
+	})
+	return postgresStorage(db)
+}
+
+func newTestSQLiteDB(t *testing.T) *storage {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := sqliteStorage(db)
+	migrator, err := newMigrator(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// startTestPostgres connects to the test server, starting one if need be,
+// and migrates the template database.
+func startTestPostgres() (*testPostgres, error) {
+	var pg *testPostgres
+	var adminDB string
+	if dbURL := os.Getenv("CHIRPY_TEST_DB_URL"); dbURL != "" {
+		u, err := url.Parse(dbURL)
+		if err != nil {
+			return nil, fmt.Errorf("CHIRPY_TEST_DB_URL: %w", err)
+		}
+		pg = &testPostgres{
+			dsn: func(dbname string) string {
+				v := *u
+				v.Path = "/" + dbname
+				return v.String()
+			},
+			stop: func() {},
+		}
+		adminDB = strings.TrimPrefix(u.Path, "/")
+	} else {
+		var err error
+		if pg, err = startLocalPostgres(); err != nil {
+			return nil, err
+		}
+		adminDB = "postgres"
+	}
+
+	admin, err := sql.Open("postgres", pg.dsn(adminDB))
+	if err == nil {
+		err = admin.Ping()
+	}
+	if err != nil {
+		pg.stop()
+		return nil, fmt.Errorf("connecting to the test postgres: %w", err)
+	}
+	pg.admin = admin
+	pg.template = fmt.Sprintf("chirpy_template_%d", os.Getpid())
+	if err := pg.migrateTemplate(); err != nil {
+		pg.close()
+		return nil, fmt.Errorf("migrating the template database: %w", err)
+	}
+	return pg, nil
+}
+
+func (pg *testPostgres) migrateTemplate() error {
+	if _, err := pg.admin.Exec("CREATE DATABASE " + pg.template); err != nil {
+		return err
+	}
+	db, err := sql.Open("postgres", pg.dsn(pg.template))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := newMigrator(postgresStorage(db))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+func (pg *testPostgres) close() {
+	pg.admin.Exec("DROP DATABASE IF EXISTS " + pg.template + " WITH (FORCE)")
+	pg.admin.Close()
+	pg.stop()
+}
+
+// startLocalPostgres runs a new cluster in a temporary directory, reachable
+// only through a unix socket there, and with durability turned off since
+// it's thrown away.
+func startLocalPostgres() (*testPostgres, error) {
+	bin, err := postgresBinDir()
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, fmt.Errorf("%w: postgres won't run as root; set CHIRPY_TEST_DB_URL", errNoPostgres)
+	}
+	dir, err := os.MkdirTemp("", "chirpy-pg-")
+	if err != nil {
+		return nil, err
+	}
+	data := filepath.Join(dir, "data")
+	initdb := exec.Command(filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %w\n%s", err, out)
+	}
+	var logs bytes.Buffer
+	server := exec.Command(filepath.Join(bin, "postgres"), "-D", data, "-k", dir,
+		"-c", "listen_addresses=",
+		"-c", "fsync=off",
+		"-c", "synchronous_commit=off",
+		"-c", "full_page_writes=off",
+	)
+	server.Stdout = &logs
+	server.Stderr = &logs
+	if err := server.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	pg := &testPostgres{
+		dsn: func(dbname string) string {
+			return fmt.Sprintf("host=%s user=postgres dbname=%s sslmode=disable", dir, dbname)
+		},
+		stop: func() {
+			server.Process.Signal(os.Interrupt)
+			server.Wait()
+			os.RemoveAll(dir)
+		},
+	}
+	if err := waitForPostgres(pg.dsn("postgres"), 30*time.Second); err != nil {
+		pg.stop()
+		return nil, fmt.Errorf("starting postgres: %w\n%s", err, logs.String())
+	}
+	return pg, nil
+}
+
+// postgresBinDir finds a directory holding both initdb and postgres.
+func postgresBinDir() (string, error) {
+	var dirs []string
+	if path, err := exec.LookPath("postgres"); err == nil {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+	installed, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	for i := len(installed) - 1; i >= 0; i-- {
+		dirs = append(dirs, installed[i])
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "initdb")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("%w: set CHIRPY_TEST_DB_URL or install postgres", errNoPostgres)
+}
+
+func waitForPostgres(dsn string, timeout time.Duration) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	deadline := time.Now().Add(timeout)
+	for {
+		err := db.Ping()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}